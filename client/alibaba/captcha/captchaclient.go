@@ -39,6 +39,9 @@ func NewCaptchaClient(options ...Option) (CaptchaClient, error) {
 		AccessKeyId:     tea.String(opts.AccessKeyID),
 		Endpoint:        tea.String(opts.EndPoint),
 	}
+	if opts.Protocol != "" {
+		config.Protocol = tea.String(opts.Protocol)
+	}
 
 	// 初始化客户端
 	client, err := captcha20230305.NewClient(config)
@@ -100,7 +103,7 @@ func handleAlibabaCloudError(err error) error {
 		if recommend != "" {
 			errMsg += fmt.Sprintf(" | 建议: %s", recommend)
 		}
-		return fmt.Errorf(errMsg)
+		return errors.New(errMsg)
 	}
 	return fmt.Errorf("未知错误: %w", err)
 }
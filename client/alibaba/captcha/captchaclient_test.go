@@ -1,23 +1,94 @@
 package captcha
 
 import (
-	"fmt"
+	"net/http"
+	"os"
+	"strings"
 	"testing"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/xhttp/xhttptest"
 )
 
-func TestVerifyCaptcha(t *testing.T) {
+func newTestClient(t *testing.T) (CaptchaClient, *xhttptest.Server) {
+	srv := xhttptest.NewServer(t)
 	client, err := NewCaptchaClient(
-		WithAccessKeySecret(""),
-		WithAccessKeyId(""),
+		WithAccessKeyId("test-ak"),
+		WithAccessKeySecret("test-sk"),
+		WithEndPoint(strings.TrimPrefix(srv.URL, "http://")),
+		WithProtocol("HTTP"),
 	)
 	if err != nil {
-		fmt.Println(err)
+		t.Fatal(err)
 	}
-	ok, err := client.VerifyIntelligentCaptcha("sssss", "zg8kzzfd")
+	return client, srv
+}
+
+func TestVerifyCaptcha(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.JSON(http.MethodPost, "/", http.StatusOK, map[string]any{
+		"RequestId": "req-1",
+		"Success":   true,
+		"Result":    map[string]any{"VerifyResult": true, "VerifyCode": VerifyIntelligentCaptchaSuccessCode},
+	})
+	srv.JSON(http.MethodPost, "/", http.StatusOK, map[string]any{
+		"RequestId": "req-2",
+		"Success":   true,
+		"Result":    map[string]any{"VerifyResult": false, "VerifyCode": "F003"},
+	})
+
+	ok, err := client.VerifyIntelligentCaptcha("param", "zg8kzzfd")
 	if err != nil {
-		fmt.Println(err)
+		t.Fatal(err)
 	}
 	if !ok {
-		fmt.Println("captcha校验失败")
+		t.Fatal("expected captcha to pass")
+	}
+	req := srv.LastRequest()
+	if action := req.Header.Get("x-acs-action"); action != "VerifyIntelligentCaptcha" {
+		t.Fatalf("unexpected action: %s", action)
+	}
+	if !strings.Contains(string(req.Body), "SceneId=zg8kzzfd") {
+		t.Fatalf("unexpected body: %s", req.Body)
+	}
+
+	ok, err = client.VerifyIntelligentCaptcha("param", "zg8kzzfd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("expected captcha to fail")
+	}
+}
+
+func TestVerifyCaptchaError(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.JSON(http.MethodPost, "/", http.StatusBadRequest, map[string]any{
+		"RequestId": "req-1",
+		"Code":      "InvalidParameter",
+		"Message":   "invalid scene",
+		"Recommend": "check scene id",
+	})
+
+	if _, err := client.VerifyIntelligentCaptcha("param", "bad"); err == nil || !strings.Contains(err.Error(), "invalid scene") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// TestVerifyCaptchaLive 调用真实服务，需设置 LOCAL_TEST 与阿里云凭据
+func TestVerifyCaptchaLive(t *testing.T) {
+	if os.Getenv("LOCAL_TEST") == "" {
+		t.Skip("set LOCAL_TEST to run against aliyun")
+	}
+	client, err := NewCaptchaClient(
+		WithAccessKeyId(os.Getenv("ALIYUN_ACCESS_KEY_ID")),
+		WithAccessKeySecret(os.Getenv("ALIYUN_ACCESS_KEY_SECRET")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := client.VerifyIntelligentCaptcha(os.Getenv("CAPTCHA_VERIFY_PARAM"), os.Getenv("CAPTCHA_SCENE_ID"))
+	if err != nil {
+		t.Fatal(err)
 	}
+	t.Logf("verify result: %v", ok)
 }
@@ -9,6 +9,7 @@ type Options struct {
 	AccessKeyID     string `json:"access_key_id"`     //ak
 	AccessKeySecret string `json:"access_key_secret"` //sk
 	EndPoint        string `json:"end_point"`         //endpoint 如captcha.cn-shanghai.aliyuncs.com
+	Protocol        string `json:"protocol"`          //请求协议 HTTPS/HTTP，为空时使用 HTTPS
 }
 
 type Option func(options *Options)
@@ -30,3 +31,9 @@ func WithEndPoint(endPoint string) Option {
 		options.EndPoint = endPoint
 	}
 }
+
+func WithProtocol(protocol string) Option {
+	return func(options *Options) {
+		options.Protocol = protocol
+	}
+}
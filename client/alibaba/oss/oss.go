@@ -1,27 +1,45 @@
 package oss
 
 import (
+	"context"
+	"errors"
 	"io"
 
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const provider = "alibaba.oss"
+
 type AliyunOss struct {
 	client *oss.Client
 }
 
 func (aliyun *AliyunOss) ListObjects(bucketName, prefix string) ([]string, error) {
+	return aliyun.ListObjectsWithContext(context.Background(), bucketName, prefix)
+}
+
+// ListObjectsWithContext 同 ListObjects，请求随 ctx 取消，并在 ctx 的 trace 中记录 span
+func (aliyun *AliyunOss) ListObjectsWithContext(ctx context.Context, bucketName, prefix string) (objects []string, err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "ListObjects",
+		attribute.String("bucket", bucketName),
+		attribute.String("object.prefix", prefix),
+	)
+	defer func() { endSpan(span, err) }()
+
 	bucket, err := aliyun.client.Bucket(bucketName)
 
 	if err != nil {
 		return nil, err
 	}
 
-	objects := make([]string, 0)
+	objects = make([]string, 0)
 
 	continueToken := ""
 	for {
-		lsRes, err := bucket.ListObjectsV2(oss.Prefix(prefix), oss.ContinuationToken(continueToken))
+		lsRes, err := bucket.ListObjectsV2(oss.Prefix(prefix), oss.ContinuationToken(continueToken), oss.WithContext(ctx))
 		if err != nil {
 			return nil, err
 		}
@@ -41,13 +59,24 @@ func (aliyun *AliyunOss) ListObjects(bucketName, prefix string) ([]string, error
 }
 
 func (aliyun *AliyunOss) PutObject(bucketName, key string, data io.Reader) error {
+	return aliyun.PutObjectWithContext(context.Background(), bucketName, key, data)
+}
+
+// PutObjectWithContext 同 PutObject，请求随 ctx 取消，并在 ctx 的 trace 中记录 span
+func (aliyun *AliyunOss) PutObjectWithContext(ctx context.Context, bucketName, key string, data io.Reader) (err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "PutObject",
+		attribute.String("bucket", bucketName),
+		attribute.String("object.key", key),
+	)
+	defer func() { endSpan(span, err) }()
+
 	bucket, err := aliyun.client.Bucket(bucketName)
 
 	if err != nil {
 		return err
 	}
 
-	if err := bucket.PutObject(key, data); err != nil {
+	if err := bucket.PutObject(key, data, oss.WithContext(ctx)); err != nil {
 		return err
 	}
 
@@ -55,13 +84,25 @@ func (aliyun *AliyunOss) PutObject(bucketName, key string, data io.Reader) error
 }
 
 func (aliyun *AliyunOss) GetObject(bucketName, key string) (io.ReadCloser, error) {
+	return aliyun.GetObjectWithContext(context.Background(), bucketName, key)
+}
+
+// GetObjectWithContext 同 GetObject，请求随 ctx 取消，并在 ctx 的 trace 中记录 span
+// span 在拿到响应头后结束，不包含读取 body 的耗时
+func (aliyun *AliyunOss) GetObjectWithContext(ctx context.Context, bucketName, key string) (body io.ReadCloser, err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "GetObject",
+		attribute.String("bucket", bucketName),
+		attribute.String("object.key", key),
+	)
+	defer func() { endSpan(span, err) }()
+
 	bucket, err := aliyun.client.Bucket(bucketName)
 
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := bucket.GetObject(key)
+	body, err = bucket.GetObject(key, oss.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -69,6 +110,15 @@ func (aliyun *AliyunOss) GetObject(bucketName, key string) (io.ReadCloser, error
 	return body, nil
 }
 
+// endSpan 记录 OSS 的错误码后结束 span
+func endSpan(span trace.Span, err error) {
+	var srvErr oss.ServiceError
+	if errors.As(err, &srvErr) {
+		span.SetAttributes(otelutils.AttrResultCode.String(srvErr.Code), attribute.Int("http.response.status_code", srvErr.StatusCode))
+	}
+	otelutils.EndSpan(span, err)
+}
+
 func NewAliyunOss(endpoint, accessKeyID, accessKeySecret string) (*AliyunOss, error) {
 	client, err := oss.New(endpoint, accessKeyID, accessKeySecret)
 
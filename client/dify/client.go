@@ -0,0 +1,356 @@
+package dify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"iter"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/client/models"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/header"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/xhttp"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
+)
+
+const provider = "dify"
+
+// Client Dify 应用 API 客户端，一个 API Key 对应一个应用
+// 只有对话请求（ChatMessages、ChatMessagesStream）支持在请求中覆盖 BaseURL 与 APIKey，
+// 会话、文件、工作流等接口始终使用 Options 中的配置，多个应用需要创建多个 Client
+type Client struct {
+	opts Options
+	http xhttp.Doer
+}
+
+func NewClient(options ...Option) *Client {
+	opts := Options{
+		Timeout:      100 * time.Second,
+		EventTimeout: 60 * time.Second,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	c := &Client{opts: opts, http: xhttp.NewClient(xhttp.WithTimeout(0))}
+	if opts.httpClient != nil {
+		c.http = opts.httpClient
+	}
+	return c
+}
+
+// chatMessageRequest Dify 接口的请求体，models.DifyChatMessageRequest 中的 BaseURL、APIKey 不发送
+type chatMessageRequest struct {
+	Inputs         map[string]any               `json:"inputs"`
+	Query          string                       `json:"query"`
+	ResponseMode   models.DifyResponseMode      `json:"response_mode"`
+	ConversationID string                       `json:"conversation_id,omitempty"`
+	User           string                       `json:"user"`
+	Files          []models.DifyChatMessageFile `json:"files,omitempty"`
+}
+
+func newChatMessageRequest(req *models.DifyChatMessageRequest, mode models.DifyResponseMode) *chatMessageRequest {
+	inputs := req.Variables
+	if inputs == nil {
+		inputs = map[string]any{}
+	}
+	return &chatMessageRequest{
+		Inputs:         inputs,
+		Query:          req.Query,
+		ResponseMode:   mode,
+		ConversationID: req.ConversationID,
+		User:           req.User,
+		Files:          req.Files,
+	}
+}
+
+// ChatMessages 以阻塞模式发送对话消息，忽略 req.ResponseMode
+func (c *Client) ChatMessages(ctx context.Context, req *models.DifyChatMessageRequest) (resp *ChatMessageResponse, err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "ChatMessages")
+	defer func() { otelutils.EndSpan(span, err) }()
+
+	baseURL, apiKey := c.credentials(req.BaseURL, req.APIKey)
+	return doJSON[chatMessageRequest, ChatMessageResponse](ctx, c, baseURL, apiKey, http.MethodPost, "/chat-messages",
+		newChatMessageRequest(req, models.DifyResponseModeBlocking))
+}
+
+// ChatMessagesStream 以流式模式发送对话消息，忽略 req.ResponseMode
+// 不返回 ping 事件，error 事件作为 *Error 返回；流在 message_end 后结束
+//
+//	for event, err := range client.ChatMessagesStream(ctx, req) {
+//		if err != nil {
+//			return err
+//		}
+//		if event.Event == dify.EventMessage {
+//			...
+//		}
+//	}
+func (c *Client) ChatMessagesStream(ctx context.Context, req *models.DifyChatMessageRequest) iter.Seq2[*StreamEvent, error] {
+	baseURL, apiKey := c.credentials(req.BaseURL, req.APIKey)
+	return c.stream(ctx, "ChatMessagesStream", baseURL, apiKey, "/chat-messages",
+		newChatMessageRequest(req, models.DifyResponseModeStreaming))
+}
+
+// StreamFuturx 同 ChatMessagesStream，事件转换为 FuturxChatCompletionStreamResponse 写入 channel
+// 出错时写入带 Error 的响应，流结束或 ctx 取消后关闭 channel
+func (c *Client) StreamFuturx(ctx context.Context, req *models.DifyChatMessageRequest) <-chan models.FuturxChatCompletionStreamResponse {
+	ch := make(chan models.FuturxChatCompletionStreamResponse)
+	go func() {
+		defer close(ch)
+		for event, err := range c.ChatMessagesStream(ctx, req) {
+			resp := models.FuturxChatCompletionStreamResponse{Error: err, Done: err != nil}
+			if err == nil {
+				resp = event.Futurx()
+			}
+			select {
+			case ch <- resp:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+type userRequest struct {
+	User string `json:"user"`
+}
+
+// StopMessage 停止流式对话的生成，taskID 来自流式事件
+func (c *Client) StopMessage(ctx context.Context, taskID, user string) (err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "StopMessage")
+	defer func() { otelutils.EndSpan(span, err) }()
+
+	_, err = doJSON[userRequest, struct{}](ctx, c, c.opts.BaseURL, c.opts.APIKey, http.MethodPost,
+		"/chat-messages/"+url.PathEscape(taskID)+"/stop", &userRequest{User: user})
+	return err
+}
+
+// Conversations 返回用户的会话列表，lastID 为上一页最后一个会话的 id，首页为空
+func (c *Client) Conversations(ctx context.Context, user, lastID string, limit int) (resp *ConversationList, err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "Conversations")
+	defer func() { otelutils.EndSpan(span, err) }()
+
+	query := url.Values{"user": {user}}
+	if lastID != "" {
+		query.Set("last_id", lastID)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	return doJSON[any, ConversationList](ctx, c, c.opts.BaseURL, c.opts.APIKey, http.MethodGet, "/conversations?"+query.Encode(), nil)
+}
+
+// Messages 返回会话的历史消息，firstID 为当前页第一条消息的 id，首页为空
+func (c *Client) Messages(ctx context.Context, conversationID, user, firstID string, limit int) (resp *MessageList, err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "Messages")
+	defer func() { otelutils.EndSpan(span, err) }()
+
+	query := url.Values{"conversation_id": {conversationID}, "user": {user}}
+	if firstID != "" {
+		query.Set("first_id", firstID)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	return doJSON[any, MessageList](ctx, c, c.opts.BaseURL, c.opts.APIKey, http.MethodGet, "/messages?"+query.Encode(), nil)
+}
+
+func (c *Client) DeleteConversation(ctx context.Context, conversationID, user string) (err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "DeleteConversation")
+	defer func() { otelutils.EndSpan(span, err) }()
+
+	_, err = doJSON[userRequest, struct{}](ctx, c, c.opts.BaseURL, c.opts.APIKey, http.MethodDelete,
+		"/conversations/"+url.PathEscape(conversationID), &userRequest{User: user})
+	return err
+}
+
+type renameConversationRequest struct {
+	Name         string `json:"name,omitempty"`
+	AutoGenerate bool   `json:"auto_generate"`
+	User         string `json:"user"`
+}
+
+// RenameConversation name 为空时由 Dify 自动生成会话名称
+func (c *Client) RenameConversation(ctx context.Context, conversationID, name, user string) (resp *Conversation, err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "RenameConversation")
+	defer func() { otelutils.EndSpan(span, err) }()
+
+	return doJSON[renameConversationRequest, Conversation](ctx, c, c.opts.BaseURL, c.opts.APIKey, http.MethodPost,
+		"/conversations/"+url.PathEscape(conversationID)+"/name",
+		&renameConversationRequest{Name: name, AutoGenerate: name == "", User: user})
+}
+
+// UploadFile 上传文件，返回的 File 通过 ChatMessageFile 用于对话请求
+func (c *Client) UploadFile(ctx context.Context, user, filename string, r io.Reader) (resp *File, err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "UploadFile")
+	defer func() { otelutils.EndSpan(span, err) }()
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, xerror.Wrap(err)
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return nil, xerror.Wrap(err)
+	}
+	if err := writer.WriteField("user", user); err != nil {
+		return nil, xerror.Wrap(err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, xerror.Wrap(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(c.opts.BaseURL, "/files/upload"), &body)
+	if err != nil {
+		return nil, xerror.Wrap(err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	c.setHeaders(ctx, req, c.opts.APIKey)
+
+	httpResp, err := c.http.Do(req)
+	if err != nil {
+		return nil, xerror.Wrap(err)
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(httpResp.Body, xhttp.DefaultMaxResponseSize))
+	if err != nil {
+		return nil, xerror.Wrap(err)
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		return nil, xerror.Wrap(convertError(&xhttp.HTTPError{StatusCode: httpResp.StatusCode, Header: httpResp.Header, Body: data}))
+	}
+
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, xerror.Wrap(err)
+	}
+	return &file, nil
+}
+
+// RunWorkflow 以阻塞模式运行工作流，忽略 req.ResponseMode
+func (c *Client) RunWorkflow(ctx context.Context, req WorkflowRunRequest) (resp *WorkflowRunResponse, err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "RunWorkflow")
+	defer func() { otelutils.EndSpan(span, err) }()
+
+	req.ResponseMode = models.DifyResponseModeBlocking
+	if req.Inputs == nil {
+		req.Inputs = map[string]any{}
+	}
+	return doJSON[WorkflowRunRequest, WorkflowRunResponse](ctx, c, c.opts.BaseURL, c.opts.APIKey, http.MethodPost, "/workflows/run", &req)
+}
+
+// RunWorkflowStream 以流式模式运行工作流，流在 workflow_finished 后结束
+func (c *Client) RunWorkflowStream(ctx context.Context, req WorkflowRunRequest) iter.Seq2[*StreamEvent, error] {
+	req.ResponseMode = models.DifyResponseModeStreaming
+	if req.Inputs == nil {
+		req.Inputs = map[string]any{}
+	}
+	return c.stream(ctx, "RunWorkflowStream", c.opts.BaseURL, c.opts.APIKey, "/workflows/run", &req)
+}
+
+// StopWorkflow 停止流式运行的工作流
+func (c *Client) StopWorkflow(ctx context.Context, taskID, user string) (err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "StopWorkflow")
+	defer func() { otelutils.EndSpan(span, err) }()
+
+	_, err = doJSON[userRequest, struct{}](ctx, c, c.opts.BaseURL, c.opts.APIKey, http.MethodPost,
+		"/workflows/tasks/"+url.PathEscape(taskID)+"/stop", &userRequest{User: user})
+	return err
+}
+
+func (c *Client) stream(ctx context.Context, operation, baseURL, apiKey, path string, body any) iter.Seq2[*StreamEvent, error] {
+	return func(yield func(*StreamEvent, error) bool) {
+		var err error
+		ctx, span := otelutils.StartClientSpan(ctx, provider, operation)
+		defer func() { otelutils.EndSpan(span, err) }()
+
+		data, err := json.Marshal(body)
+		if err != nil {
+			err = xerror.Wrap(err)
+			yield(nil, err)
+			return
+		}
+		newRequest := func(ctx context.Context) (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(baseURL, path), bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Content-Type", "application/json")
+			c.setHeaders(ctx, req, apiKey)
+			return req, nil
+		}
+
+		for event, streamErr := range xhttp.StreamJSON[StreamEvent](ctx, c.http, newRequest, xhttp.WithEventTimeout(c.opts.EventTimeout)) {
+			if streamErr != nil {
+				err = xerror.Wrap(convertError(streamErr))
+				yield(nil, err)
+				return
+			}
+			switch event.Event {
+			case EventPing:
+				continue
+			case EventError:
+				err = xerror.Wrap(event.err())
+				yield(nil, err)
+				return
+			}
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
+
+func doJSON[Req, Resp any](ctx context.Context, c *Client, baseURL, apiKey, method, path string, req *Req) (*Resp, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	// req 为 nil 时不发送请求体
+	resp, err := xhttp.DoJSON[*Req, Resp](ctx, c.http, method, c.url(baseURL, path), req,
+		xhttp.WithBearerToken(apiKey), xhttp.WithErrorDecoder(decodeError))
+	if err != nil {
+		return nil, xerror.Wrap(err)
+	}
+	return resp, nil
+}
+
+func (c *Client) credentials(baseURL, apiKey string) (string, string) {
+	if baseURL == "" {
+		baseURL = c.opts.BaseURL
+	}
+	if apiKey == "" {
+		apiKey = c.opts.APIKey
+	}
+	return baseURL, apiKey
+}
+
+// withTimeout 为阻塞请求设置超时，Timeout <= 0 时不设置
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.opts.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.opts.Timeout)
+}
+
+func (c *Client) url(baseURL, path string) string {
+	return strings.TrimSuffix(baseURL, "/") + path
+}
+
+// setHeaders 先按策略透传头部，再设置应用的 API Key，避免被入站请求的 Authorization 替换
+func (c *Client) setHeaders(ctx context.Context, req *http.Request, apiKey string) {
+	header.DefaultPolicy().Apply(ctx, req.URL.Hostname(), req.Header)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+}
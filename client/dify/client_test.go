@@ -0,0 +1,214 @@
+package dify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/client/models"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/header"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/xhttp/xhttptest"
+)
+
+func newTestClient(t *testing.T) (*Client, *xhttptest.Server) {
+	srv := xhttptest.NewServer(t)
+	return NewClient(WithBaseURL(srv.URL+"/v1"), WithAPIKey("app-test")), srv
+}
+
+func TestChatMessages(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.JSON(http.MethodPost, "/v1/chat-messages", http.StatusOK, map[string]any{
+		"event":           "message",
+		"message_id":      "m-1",
+		"conversation_id": "c-1",
+		"answer":          "hi",
+		"metadata":        map[string]any{"usage": map[string]any{"total_tokens": 12}},
+	})
+
+	resp, err := client.ChatMessages(context.Background(), &models.DifyChatMessageRequest{
+		Query:     "hello",
+		User:      "u-1",
+		Variables: map[string]any{"lang": "zh"},
+		APIKey:    "app-override",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Answer != "hi" || resp.Metadata.Usage.TotalTokens != 12 {
+		t.Fatalf("resp = %+v", resp)
+	}
+
+	req := srv.LastRequest()
+	if req.Header.Get("Authorization") != "Bearer app-override" {
+		t.Fatalf("Authorization = %q", req.Header.Get("Authorization"))
+	}
+	var body map[string]any
+	_ = json.Unmarshal(req.Body, &body)
+	if body["response_mode"] != "blocking" || body["inputs"].(map[string]any)["lang"] != "zh" || body["api_key"] != nil {
+		t.Fatalf("body = %s", req.Body)
+	}
+}
+
+func TestChatMessagesStream(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SSE(http.MethodPost, "/v1/chat-messages",
+		`{"event":"ping"}`,
+		`{"event":"agent_thought","id":"th-1","thought":"search","tool":"web"}`,
+		`{"event":"message","task_id":"t-1","conversation_id":"c-1","answer":"Hel"}`,
+		`{"event":"message","task_id":"t-1","conversation_id":"c-1","answer":"lo"}`,
+		`{"event":"message_end","task_id":"t-1","conversation_id":"c-1","metadata":{"usage":{"total_tokens":5}}}`,
+	)
+
+	var answer strings.Builder
+	var events []string
+	for resp := range client.StreamFuturx(context.Background(), &models.DifyChatMessageRequest{Query: "hi", User: "u-1"}) {
+		if resp.Error != nil {
+			t.Fatal(resp.Error)
+		}
+		events = append(events, resp.Dify.Event)
+		answer.WriteString(resp.Dify.Answer)
+		if resp.Done && resp.Dify.Event != EventMessageEnd {
+			t.Fatalf("Done on %s", resp.Dify.Event)
+		}
+	}
+	if answer.String() != "Hello" || strings.Join(events, ",") != "agent_thought,message,message,message_end" {
+		t.Fatalf("answer = %q, events = %v", answer.String(), events)
+	}
+}
+
+func TestChatMessagesStreamError(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SSE(http.MethodPost, "/v1/chat-messages",
+		`{"event":"error","status":400,"code":"provider_quota_exceeded","message":"quota exceeded"}`,
+	)
+
+	for _, err := range client.ChatMessagesStream(context.Background(), &models.DifyChatMessageRequest{Query: "hi"}) {
+		var e *Error
+		if !errors.As(err, &e) || e.Code != "provider_quota_exceeded" {
+			t.Fatalf("err = %v, want *Error", err)
+		}
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.JSON(http.MethodGet, "/v1/conversations", http.StatusNotFound, map[string]any{
+		"code": "not_found", "message": "Conversation Not Exists.", "status": 404,
+	})
+	srv.JSON(http.MethodPost, "/v1/chat-messages", http.StatusUnauthorized, map[string]any{
+		"code": "unauthorized", "message": "Access token is invalid", "status": 401,
+	})
+
+	_, err := client.Conversations(context.Background(), "u-1", "", 20)
+	var e *Error
+	if !errors.As(err, &e) || e.StatusCode != 404 || e.Code != "not_found" {
+		t.Fatalf("err = %v", err)
+	}
+	if q := srv.LastRequest().URL; !strings.Contains(q, "user=u-1") || !strings.Contains(q, "limit=20") {
+		t.Fatalf("url = %s", q)
+	}
+
+	for _, err := range client.ChatMessagesStream(context.Background(), &models.DifyChatMessageRequest{Query: "hi"}) {
+		if !errors.As(err, &e) || e.Code != "unauthorized" {
+			t.Fatalf("stream err = %v", err)
+		}
+	}
+}
+
+func TestUploadFile(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.Handle(http.MethodPost, "/v1/files/upload", func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		if r.FormValue("user") != "u-1" || header.Filename != "a.png" {
+			t.Errorf("user = %q, filename = %q", r.FormValue("user"), header.Filename)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": "f-1", "name": header.Filename, "size": header.Size})
+	})
+
+	file, err := client.UploadFile(context.Background(), "u-1", "a.png", strings.NewReader("png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := file.ChatMessageFile("image")
+	if f.UploadFileID != "f-1" || f.TransferMethod != "local_file" || file.Size != 3 {
+		t.Fatalf("file = %+v, chat file = %+v", file, f)
+	}
+}
+
+func TestRunWorkflow(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.JSON(http.MethodPost, "/v1/workflows/run", http.StatusOK, map[string]any{
+		"workflow_run_id": "r-1",
+		"task_id":         "t-1",
+		"data":            map[string]any{"status": "succeeded", "outputs": map[string]any{"text": "ok"}},
+	})
+	srv.JSON(http.MethodPost, "/v1/workflows/tasks/t-1/stop", http.StatusOK, map[string]any{"result": "success"})
+
+	resp, err := client.RunWorkflow(context.Background(), WorkflowRunRequest{User: "u-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Status != "succeeded" || resp.Data.Outputs["text"] != "ok" {
+		t.Fatalf("resp = %+v", resp)
+	}
+	if err := client.StopWorkflow(context.Background(), resp.TaskID, "u-1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAPIKeyNotReplacedByInboundAuthorization(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.SSE(http.MethodPost, "/v1/chat-messages", `{"event":"message_end"}`)
+	srv.JSON(http.MethodPost, "/v1/files/upload", http.StatusOK, map[string]any{"id": "f-1"})
+
+	in := httptest.NewRequest(http.MethodGet, "/", nil)
+	in.Header.Set(header.HeaderAuthorization, "Bearer end-user-token")
+	ctx := header.ExtractHeadersToContext(context.Background(), in)
+
+	for _, err := range client.ChatMessagesStream(ctx, &models.DifyChatMessageRequest{Query: "hi"}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := client.UploadFile(ctx, "u-1", "a.txt", strings.NewReader("a")); err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range srv.Requests() {
+		if got := req.Header.Get("Authorization"); got != "Bearer app-test" {
+			t.Fatalf("%s Authorization = %q, want app key", req.URL, got)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTimeoutDisabled(t *testing.T) {
+	srv := xhttptest.NewServer(t)
+	srv.JSON(http.MethodPost, "/v1/chat-messages", http.StatusOK, map[string]any{"answer": "hi"})
+
+	hasDeadline := true
+	client := NewClient(WithBaseURL(srv.URL+"/v1"), WithAPIKey("app-test"), WithTimeout(0),
+		WithHTTPClient(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			_, hasDeadline = req.Context().Deadline()
+			return http.DefaultTransport.RoundTrip(req)
+		})}))
+
+	if _, err := client.ChatMessages(context.Background(), &models.DifyChatMessageRequest{Query: "hello", User: "u-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if hasDeadline {
+		t.Fatal("Timeout 0 should not set a deadline")
+	}
+}
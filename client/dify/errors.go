@@ -0,0 +1,45 @@
+package dify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/xhttp"
+)
+
+// Error Dify 接口返回的错误，包括非 2xx 响应与流式响应中的 error 事件
+type Error struct {
+	StatusCode int    `json:"status"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("dify error: status %d, code %s, message: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// decodeError 解析 Dify 的错误响应，无法解析时返回 nil 由 xhttp.HTTPError 兜底
+func decodeError(resp *http.Response, body []byte) error {
+	var e Error
+	if json.Unmarshal(body, &e) != nil || (e.Code == "" && e.Message == "") {
+		return nil
+	}
+	if e.StatusCode == 0 {
+		e.StatusCode = resp.StatusCode
+	}
+	return &e
+}
+
+// convertError 将流式请求的 xhttp.HTTPError 转换为 *Error
+func convertError(err error) error {
+	var httpErr *xhttp.HTTPError
+	if !errors.As(err, &httpErr) {
+		return err
+	}
+	if e := decodeError(&http.Response{StatusCode: httpErr.StatusCode}, httpErr.Body); e != nil {
+		return e
+	}
+	return err
+}
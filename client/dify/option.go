@@ -0,0 +1,49 @@
+package dify
+
+import (
+	"net/http"
+	"time"
+)
+
+type Options struct {
+	BaseURL string `json:"base_url" yaml:"base_url"` // 如 https://api.dify.ai/v1，对话请求中的 BaseURL 优先
+	APIKey  string `json:"api_key" yaml:"api_key"`   // 应用的 API Key，对话请求中的 APIKey 优先
+	// Timeout 阻塞请求的超时，默认 100s，<= 0 表示不超时
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+	// EventTimeout 流式请求两个事件之间的超时，默认 60s，<= 0 表示不限制
+	EventTimeout time.Duration `json:"event_timeout" yaml:"event_timeout"`
+	httpClient   *http.Client
+}
+
+type Option func(*Options)
+
+func WithBaseURL(baseURL string) Option {
+	return func(o *Options) {
+		o.BaseURL = baseURL
+	}
+}
+
+func WithAPIKey(apiKey string) Option {
+	return func(o *Options) {
+		o.APIKey = apiKey
+	}
+}
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = timeout
+	}
+}
+
+func WithEventTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.EventTimeout = timeout
+	}
+}
+
+// WithHTTPClient 默认使用 xhttp.NewClient，流式请求会一直读取响应，client 不应设置 Timeout
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *Options) {
+		o.httpClient = client
+	}
+}
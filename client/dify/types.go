@@ -0,0 +1,210 @@
+package dify
+
+import (
+	"encoding/json"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/client/models"
+)
+
+// 流式响应的事件类型
+const (
+	EventMessage          = "message"
+	EventAgentMessage     = "agent_message"
+	EventAgentThought     = "agent_thought"
+	EventMessageFile      = "message_file"
+	EventMessageEnd       = "message_end"
+	EventMessageReplace   = "message_replace"
+	EventTTSMessage       = "tts_message"
+	EventTTSMessageEnd    = "tts_message_end"
+	EventWorkflowStarted  = "workflow_started"
+	EventNodeStarted      = "node_started"
+	EventNodeFinished     = "node_finished"
+	EventWorkflowFinished = "workflow_finished"
+	EventError            = "error"
+	EventPing             = "ping"
+)
+
+type Usage struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	TotalPrice       string  `json:"total_price"`
+	Currency         string  `json:"currency"`
+	Latency          float64 `json:"latency"`
+}
+
+type RetrieverResource struct {
+	Position     int     `json:"position"`
+	DatasetID    string  `json:"dataset_id"`
+	DatasetName  string  `json:"dataset_name"`
+	DocumentID   string  `json:"document_id"`
+	DocumentName string  `json:"document_name"`
+	SegmentID    string  `json:"segment_id"`
+	Score        float64 `json:"score"`
+	Content      string  `json:"content"`
+}
+
+type Metadata struct {
+	Usage              Usage               `json:"usage"`
+	RetrieverResources []RetrieverResource `json:"retriever_resources"`
+}
+
+// ChatMessageResponse 阻塞模式的对话响应
+type ChatMessageResponse struct {
+	Event          string   `json:"event"`
+	TaskID         string   `json:"task_id"`
+	ID             string   `json:"id"`
+	MessageID      string   `json:"message_id"`
+	ConversationID string   `json:"conversation_id"`
+	Mode           string   `json:"mode"`
+	Answer         string   `json:"answer"`
+	Metadata       Metadata `json:"metadata"`
+	CreatedAt      int64    `json:"created_at"`
+}
+
+// StreamEvent 流式响应的事件，不同事件使用的字段不同
+type StreamEvent struct {
+	Event          string `json:"event"`
+	TaskID         string `json:"task_id"`
+	MessageID      string `json:"message_id"`
+	ConversationID string `json:"conversation_id"`
+	CreatedAt      int64  `json:"created_at"`
+
+	// message、agent_message、message_replace
+	Answer string `json:"answer"`
+
+	// agent_thought 与 message_file 的 id
+	ID string `json:"id"`
+	// agent_thought
+	Position     int      `json:"position"`
+	Thought      string   `json:"thought"`
+	Observation  string   `json:"observation"`
+	Tool         string   `json:"tool"`
+	ToolInput    string   `json:"tool_input"`
+	MessageFiles []string `json:"message_files"`
+
+	// message_file
+	Type      string `json:"type"`
+	BelongsTo string `json:"belongs_to"`
+	URL       string `json:"url"`
+
+	// message_end
+	Metadata *Metadata `json:"metadata"`
+
+	// error
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+
+	// workflow_started、node_started、node_finished、workflow_finished
+	WorkflowRunID string          `json:"workflow_run_id"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// WorkflowRun 解析 workflow_started 与 workflow_finished 事件的 data
+func (e *StreamEvent) WorkflowRun() (*WorkflowRunData, error) {
+	var data WorkflowRunData
+	if err := json.Unmarshal(e.Data, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// Futurx 转换为通用的流式响应，message_end 标记为 Done，error 事件转换为 Error
+func (e *StreamEvent) Futurx() models.FuturxChatCompletionStreamResponse {
+	if e.Event == EventError {
+		return models.FuturxChatCompletionStreamResponse{Error: e.err(), Done: true}
+	}
+	return models.FuturxChatCompletionStreamResponse{
+		Dify: &models.DifyChatMessageResponse{
+			Event:          e.Event,
+			ConversationID: e.ConversationID,
+			Answer:         e.Answer,
+			MessageID:      e.MessageID,
+			TaskID:         e.TaskID,
+		},
+		Done: e.Event == EventMessageEnd || e.Event == EventWorkflowFinished,
+	}
+}
+
+func (e *StreamEvent) err() *Error {
+	return &Error{StatusCode: e.Status, Code: e.Code, Message: e.Message}
+}
+
+type Conversation struct {
+	ID           string         `json:"id"`
+	Name         string         `json:"name"`
+	Inputs       map[string]any `json:"inputs"`
+	Status       string         `json:"status"`
+	Introduction string         `json:"introduction"`
+	CreatedAt    int64          `json:"created_at"`
+	UpdatedAt    int64          `json:"updated_at"`
+}
+
+type ConversationList struct {
+	Data    []Conversation `json:"data"`
+	HasMore bool           `json:"has_more"`
+	Limit   int            `json:"limit"`
+}
+
+type Message struct {
+	ID                 string              `json:"id"`
+	ConversationID     string              `json:"conversation_id"`
+	Inputs             map[string]any      `json:"inputs"`
+	Query              string              `json:"query"`
+	Answer             string              `json:"answer"`
+	RetrieverResources []RetrieverResource `json:"retriever_resources"`
+	CreatedAt          int64               `json:"created_at"`
+}
+
+type MessageList struct {
+	Data    []Message `json:"data"`
+	HasMore bool      `json:"has_more"`
+	Limit   int       `json:"limit"`
+}
+
+// File UploadFile 返回的文件信息
+type File struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Size      int64  `json:"size"`
+	Extension string `json:"extension"`
+	MimeType  string `json:"mime_type"`
+	CreatedBy string `json:"created_by"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// ChatMessageFile 转换为对话请求中的本地文件，fileType 为 image、document、audio、video 等
+func (f *File) ChatMessageFile(fileType string) models.DifyChatMessageFile {
+	return models.DifyChatMessageFile{
+		Type:           fileType,
+		TransferMethod: "local_file",
+		UploadFileID:   f.ID,
+	}
+}
+
+type WorkflowRunRequest struct {
+	Inputs       map[string]any               `json:"inputs"`
+	ResponseMode models.DifyResponseMode      `json:"response_mode"`
+	User         string                       `json:"user"`
+	Files        []models.DifyChatMessageFile `json:"files,omitempty"`
+}
+
+type WorkflowRunData struct {
+	ID          string         `json:"id"`
+	WorkflowID  string         `json:"workflow_id"`
+	Status      string         `json:"status"`
+	Outputs     map[string]any `json:"outputs"`
+	Error       string         `json:"error"`
+	ElapsedTime float64        `json:"elapsed_time"`
+	TotalTokens int            `json:"total_tokens"`
+	TotalSteps  int            `json:"total_steps"`
+	CreatedAt   int64          `json:"created_at"`
+	FinishedAt  int64          `json:"finished_at"`
+}
+
+type WorkflowRunResponse struct {
+	WorkflowRunID string          `json:"workflow_run_id"`
+	TaskID        string          `json:"task_id"`
+	Data          WorkflowRunData `json:"data"`
+}
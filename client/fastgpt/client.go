@@ -0,0 +1,213 @@
+package fastgpt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/client/models"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/header"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/xhttp"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const provider = "fastgpt"
+
+// Client FastGPT 对话接口客户端，也可用于任意 OpenAI 兼容的 /chat/completions 接口
+type Client struct {
+	opts Options
+	http xhttp.Doer
+}
+
+func NewClient(options ...Option) *Client {
+	opts := Options{
+		Timeout:      120 * time.Second,
+		EventTimeout: 60 * time.Second,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	c := &Client{opts: opts, http: xhttp.NewClient(xhttp.WithTimeout(0))}
+	if opts.httpClient != nil {
+		c.http = opts.httpClient
+	}
+	return c
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// chatCompletionRequest 接口的请求体，models.FastGPTChatCompletionRequest 中的 BaseURL、APIKey 不发送
+type chatCompletionRequest struct {
+	Model         string                             `json:"model"`
+	Messages      []models.FastGPTChatRequestMessage `json:"messages"`
+	Stream        bool                               `json:"stream"`
+	StreamOptions *streamOptions                     `json:"stream_options,omitempty"`
+	Variables     map[string]any                     `json:"variables,omitempty"`
+	ChatID        string                             `json:"chatId,omitempty"`
+	Tools         []models.FastGPTTool               `json:"tools,omitempty"`
+	ToolChoice    any                                `json:"tool_choice,omitempty"`
+	Temperature   *float64                           `json:"temperature,omitempty"`
+	MaxTokens     int                                `json:"max_tokens,omitempty"`
+}
+
+func (c *Client) newRequest(req *models.FastGPTChatCompletionRequest, stream bool) *chatCompletionRequest {
+	r := &chatCompletionRequest{
+		Model:       req.Model,
+		Messages:    req.Messages,
+		Stream:      stream,
+		Variables:   req.Variables,
+		ChatID:      req.ChatID,
+		Tools:       req.Tools,
+		ToolChoice:  req.ToolChoice,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	}
+	if stream && c.opts.StreamUsage {
+		r.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+	return r
+}
+
+// ChatCompletion 发送阻塞对话请求，忽略 req.Stream
+func (c *Client) ChatCompletion(ctx context.Context, req *models.FastGPTChatCompletionRequest) (resp *models.FastGPTChatCompletionResponse, err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "ChatCompletion", attribute.String("gen_ai.request.model", req.Model))
+	defer func() { otelutils.EndSpan(span, err) }()
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	baseURL, apiKey := c.credentials(req)
+	resp, err = xhttp.DoJSON[*chatCompletionRequest, models.FastGPTChatCompletionResponse](ctx, c.http, http.MethodPost,
+		c.url(baseURL), c.newRequest(req, false),
+		xhttp.WithBearerToken(apiKey), xhttp.WithErrorDecoder(decodeError))
+	if err != nil {
+		return nil, xerror.Wrap(err)
+	}
+	if resp.Error != nil {
+		return nil, xerror.Wrap(&Error{StatusCode: http.StatusOK, FastGPTChatCompletionError: *resp.Error})
+	}
+
+	c.recordUsage(ctx, span, req.Model, resp.Usage)
+	return resp, nil
+}
+
+// ChatCompletionStream 发送流式对话请求，忽略 req.Stream，逐个返回分片，收到 [DONE] 后结束
+// 使用 StreamAccumulator 合并分片中的内容与工具调用
+func (c *Client) ChatCompletionStream(ctx context.Context, req *models.FastGPTChatCompletionRequest) iter.Seq2[*models.FastGPTChatCompletionResponse, error] {
+	return func(yield func(*models.FastGPTChatCompletionResponse, error) bool) {
+		var err error
+		ctx, span := otelutils.StartClientSpan(ctx, provider, "ChatCompletionStream", attribute.String("gen_ai.request.model", req.Model))
+		defer func() { otelutils.EndSpan(span, err) }()
+
+		baseURL, apiKey := c.credentials(req)
+		data, err := json.Marshal(c.newRequest(req, true))
+		if err != nil {
+			err = xerror.Wrap(err)
+			yield(nil, err)
+			return
+		}
+		newRequest := func(ctx context.Context) (*http.Request, error) {
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(baseURL), bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			httpReq.Header.Set("Content-Type", "application/json")
+			// 先透传头部再设置 API Key，避免被入站请求的 Authorization 替换
+			header.DefaultPolicy().Apply(ctx, httpReq.URL.Hostname(), httpReq.Header)
+			httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+			return httpReq, nil
+		}
+
+		for chunk, streamErr := range xhttp.StreamJSON[models.FastGPTChatCompletionResponse](ctx, c.http, newRequest, xhttp.WithEventTimeout(c.opts.EventTimeout)) {
+			if streamErr != nil {
+				err = xerror.Wrap(convertError(streamErr))
+				yield(nil, err)
+				return
+			}
+			if chunk.Error != nil {
+				err = xerror.Wrap(&Error{StatusCode: http.StatusOK, FastGPTChatCompletionError: *chunk.Error})
+				yield(nil, err)
+				return
+			}
+			c.recordUsage(ctx, span, req.Model, chunk.Usage)
+			if !yield(chunk, nil) {
+				return
+			}
+		}
+	}
+}
+
+// StreamFuturx 同 ChatCompletionStream，分片转换为 FuturxChatCompletionStreamResponse 写入 channel
+// 流正常结束时写入 Done 为 true 的响应，出错时写入带 Error 的响应，之后关闭 channel
+func (c *Client) StreamFuturx(ctx context.Context, req *models.FastGPTChatCompletionRequest) <-chan models.FuturxChatCompletionStreamResponse {
+	ch := make(chan models.FuturxChatCompletionStreamResponse)
+	go func() {
+		defer close(ch)
+		send := func(resp models.FuturxChatCompletionStreamResponse) bool {
+			select {
+			case ch <- resp:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for chunk, err := range c.ChatCompletionStream(ctx, req) {
+			if err != nil {
+				send(models.FuturxChatCompletionStreamResponse{Error: err, Done: true})
+				return
+			}
+			if !send(models.FuturxChatCompletionStreamResponse{FastGPT: chunk}) {
+				return
+			}
+		}
+		send(models.FuturxChatCompletionStreamResponse{Done: true})
+	}()
+	return ch
+}
+
+func (c *Client) recordUsage(ctx context.Context, span trace.Span, model string, usage *models.FastGPTUsage) {
+	if usage == nil {
+		return
+	}
+	span.SetAttributes(
+		attribute.Int("gen_ai.usage.input_tokens", usage.PromptTokens),
+		attribute.Int("gen_ai.usage.output_tokens", usage.CompletionTokens),
+	)
+	if c.opts.OnUsage != nil {
+		c.opts.OnUsage(ctx, model, *usage)
+	}
+}
+
+func (c *Client) credentials(req *models.FastGPTChatCompletionRequest) (string, string) {
+	baseURL, apiKey := req.BaseURL, req.APIKey
+	if baseURL == "" {
+		baseURL = c.opts.BaseURL
+	}
+	if apiKey == "" {
+		apiKey = c.opts.APIKey
+	}
+	return baseURL, apiKey
+}
+
+// withTimeout 为阻塞请求设置超时，Timeout <= 0 时不设置
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.opts.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.opts.Timeout)
+}
+
+func (c *Client) url(baseURL string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/chat/completions"
+}
@@ -0,0 +1,200 @@
+package fastgpt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/client/models"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/header"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/xhttp/xhttptest"
+)
+
+func TestChatCompletion(t *testing.T) {
+	srv := xhttptest.NewServer(t)
+	srv.JSON(http.MethodPost, "/api/v1/chat/completions", http.StatusOK, map[string]any{
+		"id": "cmpl-1",
+		"choices": []map[string]any{{
+			"message":       map[string]any{"role": "assistant", "content": "a cat"},
+			"finish_reason": "stop",
+		}},
+		"usage": map[string]any{"prompt_tokens": 10, "completion_tokens": 2, "total_tokens": 12},
+	})
+
+	var usage models.FastGPTUsage
+	client := NewClient(WithBaseURL(srv.URL+"/api/v1"), WithAPIKey("fastgpt-key"),
+		WithOnUsage(func(ctx context.Context, model string, u models.FastGPTUsage) { usage = u }))
+
+	resp, err := client.ChatCompletion(context.Background(), &models.FastGPTChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []models.FastGPTChatRequestMessage{NewUserMessage("what is it?", "https://example.com/cat.png")},
+		Stream:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Choices[0].Message.Content != "a cat" || usage.TotalTokens != 12 {
+		t.Fatalf("resp = %+v, usage = %+v", resp, usage)
+	}
+
+	req := srv.LastRequest()
+	if req.Header.Get("Authorization") != "Bearer fastgpt-key" {
+		t.Fatalf("Authorization = %q", req.Header.Get("Authorization"))
+	}
+	var body struct {
+		Stream   bool                                 `json:"stream"`
+		APIKey   *string                              `json:"api_key"`
+		Messages []struct{ Content []map[string]any } `json:"messages"`
+	}
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Stream || body.APIKey != nil || body.Messages[0].Content[1]["type"] != "image_url" {
+		t.Fatalf("body = %s", req.Body)
+	}
+}
+
+func TestChatCompletionStreamToolCalls(t *testing.T) {
+	srv := xhttptest.NewServer(t)
+	srv.SSE(http.MethodPost, "/v1/chat/completions",
+		`{"id":"c-1","choices":[{"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call-1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+		`{"id":"c-1","choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+		`{"id":"c-1","choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]},"finish_reason":"tool_calls"}]}`,
+		`{"id":"c-1","choices":[],"usage":{"prompt_tokens":20,"completion_tokens":8,"total_tokens":28}}`,
+		`[DONE]`,
+	)
+
+	client := NewClient(WithBaseURL(srv.URL+"/v1"), WithStreamUsage())
+	req := &models.FastGPTChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []models.FastGPTChatRequestMessage{NewUserMessage("weather in Paris?")},
+		Tools: []models.FastGPTTool{NewFunctionTool("get_weather", "Get weather", map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"city": map[string]any{"type": "string"}},
+		})},
+	}
+
+	var acc StreamAccumulator
+	for chunk, err := range client.ChatCompletionStream(context.Background(), req) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		acc.Add(chunk)
+	}
+
+	resp := acc.Response()
+	calls := resp.Choices[0].Message.ToolCalls
+	if len(calls) != 1 || calls[0].ID != "call-1" || calls[0].Function.Name != "get_weather" || calls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Fatalf("tool calls = %+v", calls)
+	}
+	if resp.Choices[0].FinishReason != "tool_calls" || resp.Usage == nil || resp.Usage.TotalTokens != 28 {
+		t.Fatalf("resp = %+v", resp)
+	}
+
+	var body map[string]any
+	_ = json.Unmarshal(srv.LastRequest().Body, &body)
+	if body["stream"] != true || body["stream_options"].(map[string]any)["include_usage"] != true {
+		t.Fatalf("body = %s", srv.LastRequest().Body)
+	}
+}
+
+func TestStreamFuturx(t *testing.T) {
+	srv := xhttptest.NewServer(t)
+	srv.SSE(http.MethodPost, "/chat/completions",
+		`{"id":"c-1","choices":[{"delta":{"content":"Hel"}}]}`,
+		`{"id":"c-1","choices":[{"delta":{"content":"lo"}}]}`,
+		`[DONE]`,
+	)
+
+	client := NewClient(WithBaseURL(srv.URL))
+	var content string
+	var done bool
+	for resp := range client.StreamFuturx(context.Background(), &models.FastGPTChatCompletionRequest{Model: "m"}) {
+		if resp.Error != nil {
+			t.Fatal(resp.Error)
+		}
+		if resp.Done {
+			done = true
+			continue
+		}
+		content += resp.FastGPT.Choices[0].Delta.Content
+	}
+	if content != "Hello" || !done {
+		t.Fatalf("content = %q, done = %v", content, done)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	srv := xhttptest.NewServer(t)
+	srv.JSON(http.MethodPost, "/openai/chat/completions", http.StatusUnauthorized, map[string]any{
+		"error": map[string]any{"message": "Incorrect API key", "type": "invalid_request_error", "code": "invalid_api_key"},
+	})
+	srv.JSON(http.MethodPost, "/fastgpt/chat/completions", http.StatusInternalServerError, map[string]any{
+		"code": 514, "statusText": "unAuthApiKey", "message": "Api Key 不合法",
+	})
+
+	_, err := NewClient(WithBaseURL(srv.URL+"/openai")).ChatCompletion(context.Background(), &models.FastGPTChatCompletionRequest{Model: "m"})
+	var e *Error
+	if !errors.As(err, &e) || e.StatusCode != http.StatusUnauthorized || e.Code != "invalid_api_key" {
+		t.Fatalf("err = %v", err)
+	}
+
+	_, err = NewClient(WithBaseURL(srv.URL+"/fastgpt")).ChatCompletion(context.Background(), &models.FastGPTChatCompletionRequest{Model: "m"})
+	if !errors.As(err, &e) || e.Message != "Api Key 不合法" || e.Code != float64(514) {
+		t.Fatalf("err = %v", err)
+	}
+
+	srv.SSE(http.MethodPost, "/stream/chat/completions", `{"error":{"message":"context length exceeded","code":"context_length_exceeded"}}`)
+	for _, err := range NewClient(WithBaseURL(srv.URL+"/stream")).ChatCompletionStream(context.Background(), &models.FastGPTChatCompletionRequest{Model: "m"}) {
+		if !errors.As(err, &e) || e.Message != "context length exceeded" {
+			t.Fatalf("stream err = %v", err)
+		}
+	}
+}
+
+func TestAPIKeyNotReplacedByInboundAuthorization(t *testing.T) {
+	srv := xhttptest.NewServer(t)
+	srv.SSE(http.MethodPost, "/v1/chat/completions", `[DONE]`)
+
+	in := httptest.NewRequest(http.MethodGet, "/", nil)
+	in.Header.Set(header.HeaderAuthorization, "Bearer end-user-token")
+	ctx := header.ExtractHeadersToContext(context.Background(), in)
+
+	client := NewClient(WithBaseURL(srv.URL+"/v1"), WithAPIKey("sk-app"))
+	for _, err := range client.ChatCompletionStream(ctx, &models.FastGPTChatCompletionRequest{Model: "m"}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := srv.LastRequest().Header.Get("Authorization"); got != "Bearer sk-app" {
+		t.Fatalf("Authorization = %q, want app key", got)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTimeoutDisabled(t *testing.T) {
+	srv := xhttptest.NewServer(t)
+	srv.JSON(http.MethodPost, "/api/v1/chat/completions", http.StatusOK, map[string]any{"id": "cmpl-1"})
+
+	hasDeadline := true
+	client := NewClient(WithBaseURL(srv.URL+"/api/v1"), WithAPIKey("fastgpt-key"), WithTimeout(0),
+		WithHTTPClient(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			_, hasDeadline = req.Context().Deadline()
+			return http.DefaultTransport.RoundTrip(req)
+		})}))
+
+	if _, err := client.ChatCompletion(context.Background(), &models.FastGPTChatCompletionRequest{Model: "gpt-4o"}); err != nil {
+		t.Fatal(err)
+	}
+	if hasDeadline {
+		t.Fatal("Timeout 0 should not set a deadline")
+	}
+}
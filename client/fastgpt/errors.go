@@ -0,0 +1,51 @@
+package fastgpt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/client/models"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/xhttp"
+)
+
+// Error 接口返回的错误，兼容 OpenAI 的 {"error": {...}} 与 FastGPT 的 {"code": ..., "message": ...}
+type Error struct {
+	StatusCode int
+	models.FastGPTChatCompletionError
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("fastgpt error: status %d, code %v, message: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// decodeError 无法解析时返回 nil 由 xhttp.HTTPError 兜底
+func decodeError(resp *http.Response, body []byte) error {
+	var v struct {
+		Error *models.FastGPTChatCompletionError `json:"error"`
+		models.FastGPTChatCompletionError
+	}
+	if json.Unmarshal(body, &v) != nil {
+		return nil
+	}
+	if v.Error != nil {
+		return &Error{StatusCode: resp.StatusCode, FastGPTChatCompletionError: *v.Error}
+	}
+	if v.Message != "" {
+		return &Error{StatusCode: resp.StatusCode, FastGPTChatCompletionError: v.FastGPTChatCompletionError}
+	}
+	return nil
+}
+
+// convertError 将流式请求的 xhttp.HTTPError 转换为 *Error
+func convertError(err error) error {
+	var httpErr *xhttp.HTTPError
+	if !errors.As(err, &httpErr) {
+		return err
+	}
+	if e := decodeError(&http.Response{StatusCode: httpErr.StatusCode}, httpErr.Body); e != nil {
+		return e
+	}
+	return err
+}
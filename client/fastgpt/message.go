@@ -0,0 +1,138 @@
+package fastgpt
+
+import (
+	"strings"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/client/models"
+)
+
+func NewTextContent(text string) models.FastGPTChatMessageContent {
+	return models.FastGPTChatMessageContent{
+		Type: models.FastGPTChatMessageContentText,
+		Text: &text,
+	}
+}
+
+// NewImageContent url 可以是图片地址或 data:image/png;base64,... 格式
+func NewImageContent(url string) models.FastGPTChatMessageContent {
+	return models.FastGPTChatMessageContent{
+		Type:     models.FastGPTChatMessageContentImageURL,
+		ImageURL: &models.ImageURL{URL: url},
+	}
+}
+
+// NewUserMessage 没有图片时 content 为字符串，有图片时为多模态内容
+func NewUserMessage(text string, imageURLs ...string) models.FastGPTChatRequestMessage {
+	if len(imageURLs) == 0 {
+		return models.FastGPTChatRequestMessage{Role: models.FastGPTChatMessageRoleUser, Content: text}
+	}
+	contents := []models.FastGPTChatMessageContent{NewTextContent(text)}
+	for _, url := range imageURLs {
+		contents = append(contents, NewImageContent(url))
+	}
+	return models.FastGPTChatRequestMessage{Role: models.FastGPTChatMessageRoleUser, Content: contents}
+}
+
+// NewToolMessage 工具执行结果，toolCallID 为模型返回的工具调用 id
+func NewToolMessage(toolCallID, content string) models.FastGPTChatRequestMessage {
+	return models.FastGPTChatRequestMessage{
+		Role:       models.FastGPTChatMessageRoleTool,
+		Content:    content,
+		ToolCallID: toolCallID,
+	}
+}
+
+// NewFunctionTool 创建 function 类型的工具，parameters 为 JSON Schema
+func NewFunctionTool(name, description string, parameters any) models.FastGPTTool {
+	return models.FastGPTTool{
+		Type: "function",
+		Function: models.FastGPTFunctionDef{
+			Name:        name,
+			Description: description,
+			Parameters:  parameters,
+		},
+	}
+}
+
+// StreamAccumulator 合并流式响应的分片，得到与阻塞请求相同的结果
+//
+//	var acc fastgpt.StreamAccumulator
+//	for chunk, err := range client.ChatCompletionStream(ctx, req) {
+//		...
+//		acc.Add(chunk)
+//	}
+//	resp := acc.Response()
+type StreamAccumulator struct {
+	id           string
+	model        string
+	role         string
+	content      strings.Builder
+	toolCalls    []models.FastGPTToolCall
+	finishReason string
+	usage        *models.FastGPTUsage
+}
+
+// Add 合并一个分片，只处理第一个 choice
+func (a *StreamAccumulator) Add(chunk *models.FastGPTChatCompletionResponse) {
+	if chunk.ID != "" {
+		a.id = chunk.ID
+	}
+	if chunk.Model != "" {
+		a.model = chunk.Model
+	}
+	if chunk.Usage != nil {
+		a.usage = chunk.Usage
+	}
+	if len(chunk.Choices) == 0 {
+		return
+	}
+
+	choice := chunk.Choices[0]
+	if choice.Delta.Role != "" {
+		a.role = choice.Delta.Role
+	}
+	if choice.FinishReason != "" {
+		a.finishReason = choice.FinishReason
+	}
+	a.content.WriteString(choice.Delta.Content)
+
+	for i, call := range choice.Delta.ToolCalls {
+		index := i
+		if call.Index != nil {
+			index = *call.Index
+		}
+		for len(a.toolCalls) <= index {
+			a.toolCalls = append(a.toolCalls, models.FastGPTToolCall{})
+		}
+		merged := &a.toolCalls[index]
+		if call.ID != "" {
+			merged.ID = call.ID
+		}
+		if call.Type != "" {
+			merged.Type = call.Type
+		}
+		merged.Function.Name += call.Function.Name
+		merged.Function.Arguments += call.Function.Arguments
+	}
+}
+
+// Response 返回合并后的响应，Choices[0].Message 为完整消息
+func (a *StreamAccumulator) Response() *models.FastGPTChatCompletionResponse {
+	role := a.role
+	if role == "" {
+		role = models.FastGPTChatMessageRoleAssistant
+	}
+	return &models.FastGPTChatCompletionResponse{
+		ID:    a.id,
+		Model: a.model,
+		Choices: []models.FastGPTChatCompletionChoice{{
+			Message: models.FastGPTChatResponseMessage{
+				Role:      role,
+				Content:   a.content.String(),
+				ToolCalls: a.toolCalls,
+			},
+			FinishReason: a.finishReason,
+		}},
+		Usage: a.usage,
+	}
+}
@@ -0,0 +1,69 @@
+package fastgpt
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/client/models"
+)
+
+type Options struct {
+	BaseURL string `json:"base_url" yaml:"base_url"` // 如 https://api.fastgpt.in/api/v1，请求中的 BaseURL 优先
+	APIKey  string `json:"api_key" yaml:"api_key"`   // 请求中的 APIKey 优先
+	// Timeout 阻塞请求的超时，默认 120s，<= 0 表示不超时
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+	// EventTimeout 流式请求两个分片之间的超时，默认 60s，<= 0 表示不限制
+	EventTimeout time.Duration `json:"event_timeout" yaml:"event_timeout"`
+	// StreamUsage 流式请求设置 stream_options.include_usage，在最后一个分片返回 token 用量
+	StreamUsage bool `json:"stream_usage" yaml:"stream_usage"`
+	// OnUsage 请求返回 token 用量时回调，用于计费与统计
+	OnUsage    func(ctx context.Context, model string, usage models.FastGPTUsage)
+	httpClient *http.Client
+}
+
+type Option func(*Options)
+
+func WithBaseURL(baseURL string) Option {
+	return func(o *Options) {
+		o.BaseURL = baseURL
+	}
+}
+
+func WithAPIKey(apiKey string) Option {
+	return func(o *Options) {
+		o.APIKey = apiKey
+	}
+}
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = timeout
+	}
+}
+
+func WithEventTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.EventTimeout = timeout
+	}
+}
+
+// WithStreamUsage 部分 OpenAI 兼容服务不支持 stream_options，确认支持后再开启
+func WithStreamUsage() Option {
+	return func(o *Options) {
+		o.StreamUsage = true
+	}
+}
+
+func WithOnUsage(fn func(ctx context.Context, model string, usage models.FastGPTUsage)) Option {
+	return func(o *Options) {
+		o.OnUsage = fn
+	}
+}
+
+// WithHTTPClient 默认使用 xhttp.NewClient，流式请求会一直读取响应，client 不应设置 Timeout
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *Options) {
+		o.httpClient = client
+	}
+}
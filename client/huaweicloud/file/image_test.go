@@ -1,60 +1,70 @@
 package file
 
 import (
-	"fmt"
+	"net/http"
 	"os"
-	"path"
+	"strings"
 	"testing"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/xhttp/xhttptest"
 )
 
-// TestUploadFile 是一个测试函数，用于上传一个本地的文件。
-func TestUploadMP4File(t *testing.T) {
-	const (
-		accessKeyID     = "MJ5FDJVQWIET3U8LATIJ"
-		accessKeySecret = "jUsHBpNbr869L5x1fjY5JtrqUpYZKiOSiGUyXism"
-		endpoint        = "https://obs.cn-east-3.myhuaweicloud.com"
-		bucket          = "futurx"
-		cdnDomain       = "https://futurx.obs.cn-east-3.myhuaweicloud.com"
-		uploadPrefix    = "test/projectflow"
-	)
+func TestUploadFile(t *testing.T) {
+	srv := xhttptest.NewServer(t)
+	srv.Handle(http.MethodPut, "/futurx/test/projectflow/test.gif", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"etag"`)
+		w.WriteHeader(http.StatusOK)
+	})
+
 	client, err := NewObsFileClient(
-		WidthAccessKeyID(accessKeyID),
-		WithAccessKeySecret(accessKeySecret),
-		WithEndpoint(endpoint),
-		WithBucket(bucket),
-		WithCDN(cdnDomain),
-		WithPrefix(uploadPrefix),
+		WidthAccessKeyID("ak"),
+		WithAccessKeySecret("sk"),
+		WithEndpoint(srv.URL),
+		WithBucket("futurx"),
+		WithCDN("https://cdn.example.com"),
+		WithPrefix("test/projectflow"),
 	)
 	if err != nil {
-		t.Fatalf("创建 ObsFileClient 失败: %v", err)
+		t.Fatal(err)
 	}
 
-	const localFilePath = "/Users/kongliren/Downloads/new首页720.gif"
-	file, err := os.Open(localFilePath)
+	url, err := client.UploadFile("test.gif", strings.NewReader("GIF89a"))
 	if err != nil {
-		t.Fatalf("打开本地文件 '%s' 失败: %v", localFilePath, err)
+		t.Fatal(err)
 	}
-	defer file.Close()
-
-	const objectKey = "test-Think.gif"
+	if url != "https://cdn.example.com/test/projectflow/test.gif" {
+		t.Fatalf("url = %q", url)
+	}
+	if body := string(srv.LastRequest().Body); body != "GIF89a" {
+		t.Fatalf("body = %q", body)
+	}
+}
 
-	t.Logf("开始上传文件 '%s' 到 OBS...", localFilePath)
+// TestUploadFileLive 上传到真实的 OBS，凭证通过环境变量传入
+func TestUploadFileLive(t *testing.T) {
+	if os.Getenv("LOCAL_TEST") != "true" {
+		t.Skip("skip: only run when LOCAL_TEST=true")
+	}
+	accessKeyID, accessKeySecret := os.Getenv("OBS_ACCESS_KEY_ID"), os.Getenv("OBS_ACCESS_KEY_SECRET")
+	if accessKeyID == "" || accessKeySecret == "" {
+		t.Skip("skip: OBS_ACCESS_KEY_ID and OBS_ACCESS_KEY_SECRET are required")
+	}
 
-	returnedURL, err := client.UploadFile(objectKey, file)
+	client, err := NewObsFileClient(
+		WidthAccessKeyID(accessKeyID),
+		WithAccessKeySecret(accessKeySecret),
+		WithEndpoint("https://obs.cn-east-3.myhuaweicloud.com"),
+		WithBucket("futurx"),
+		WithCDN("https://futurx.obs.cn-east-3.myhuaweicloud.com"),
+		WithPrefix("test/projectflow"),
+	)
 	if err != nil {
-		t.Errorf("UploadFile 方法执行失败: %v", err)
-		return
+		t.Fatal(err)
 	}
 
-	// 构造期望的 URL 格式进行比对。
-	expectedURL := fmt.Sprintf("%s%s", cdnDomain, path.Join("/", uploadPrefix, objectKey))
-
-	if returnedURL == "" {
-		t.Error("返回的 URL 为空，期望得到一个有效的 URL。")
-	} else if returnedURL != expectedURL {
-		t.Errorf("返回的 URL 与期望不符。\n期望: %s\n实际: %s", expectedURL, returnedURL)
-	} else {
-		t.Logf("文件上传成功！")
-		t.Logf("返回的 URL 是: %s", returnedURL)
+	url, err := client.UploadFile("test.gif", strings.NewReader("GIF89a"))
+	if err != nil {
+		t.Fatal(err)
 	}
+	t.Log(url)
 }
@@ -2,6 +2,7 @@ package msgsms
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,7 +10,9 @@ import (
 	"net/url"
 
 	"github.com/Yet-Another-AI-Project/kiwi-lib/client/huaweicloud/core"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
 	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type SendSMSResponse struct {
@@ -18,7 +21,22 @@ type SendSMSResponse struct {
 	Result      string `json:"result"`
 }
 
+const provider = "huaweicloud.sms"
+
 func (h *HuaweiCloudSMS) SendSMS(receiver string, templateParams map[string]string) (*SendSMSResponse, error) {
+	return h.SendSMSWithContext(context.Background(), receiver, templateParams)
+}
+
+// SendSMSWithContext 同 SendSMS，请求随 ctx 取消，并在 ctx 的 trace 中记录 span
+func (h *HuaweiCloudSMS) SendSMSWithContext(ctx context.Context, receiver string, templateParams map[string]string) (result *SendSMSResponse, err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "SendSMS", attribute.String("sms.template_id", h.templateId))
+	defer func() {
+		if result != nil {
+			span.SetAttributes(otelutils.AttrResultCode.String(result.Code))
+		}
+		otelutils.EndSpan(span, err)
+	}()
+
 	appInfo := core.Signer{
 		Key:    h.key,
 		Secret: h.secret,
@@ -32,7 +50,7 @@ func (h *HuaweiCloudSMS) SendSMS(receiver string, templateParams map[string]stri
 	}
 
 	body := h.buildRequestBody(receiver, string(tp))
-	resp, err := h.post(url, []byte(body), appInfo)
+	resp, err := h.post(ctx, url, []byte(body), appInfo)
 
 	if err != nil {
 		return nil, xerror.Wrap(err)
@@ -58,12 +76,12 @@ func (h *HuaweiCloudSMS) buildRequestBody(receiver, templateParas string) string
 	return param
 }
 
-func (h *HuaweiCloudSMS) post(url string, param []byte, appInfo core.Signer) ([]byte, error) {
+func (h *HuaweiCloudSMS) post(ctx context.Context, url string, param []byte, appInfo core.Signer) ([]byte, error) {
 	if param == nil || appInfo == (core.Signer{}) {
 		return nil, fmt.Errorf("param or appInfo is nil")
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(param))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(param))
 	if err != nil {
 		return nil, xerror.Wrap(err)
 	}
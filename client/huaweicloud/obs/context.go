@@ -0,0 +1,109 @@
+package obs
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+)
+
+// requestContext 可替换的 ctx，OBS SDK 只在创建 client 时接收 ctx
+type requestContext struct {
+	ctx atomic.Pointer[context.Context]
+}
+
+func (c *requestContext) get() context.Context {
+	if ctx := c.ctx.Load(); ctx != nil {
+		return *ctx
+	}
+	return context.Background()
+}
+
+func (c *requestContext) Deadline() (time.Time, bool) { return c.get().Deadline() }
+func (c *requestContext) Done() <-chan struct{}       { return c.get().Done() }
+func (c *requestContext) Err() error                  { return c.get().Err() }
+func (c *requestContext) Value(key any) any           { return c.get().Value(key) }
+
+type contextClient struct {
+	*obs.ObsClient
+	ctx *requestContext
+}
+
+// clientPool 复用带 ctx 的 client，各 client 共用同一个 transport
+// 取出时绑定调用方的 ctx，请求结束（GetObject 为 Body 关闭）后放回
+type clientPool struct {
+	pool sync.Pool
+}
+
+func newClientPool(accessKeyID, accessKeySecret, endpoint string) (*clientPool, error) {
+	transport := newTransport()
+	newClient := func() (*contextClient, error) {
+		ctx := &requestContext{}
+		client, err := obs.New(accessKeyID, accessKeySecret, endpoint,
+			obs.WithHttpTransport(transport),
+			obs.WithRequestContext(ctx),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return &contextClient{ObsClient: client, ctx: ctx}, nil
+	}
+
+	// 先创建一个 client 校验配置，之后以相同配置创建不会失败
+	client, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	p := &clientPool{}
+	p.pool.New = func() any {
+		client, _ := newClient()
+		return client
+	}
+	p.pool.Put(client)
+	return p, nil
+}
+
+func (p *clientPool) get(ctx context.Context) *contextClient {
+	client := p.pool.Get().(*contextClient)
+	client.ctx.ctx.Store(&ctx)
+	return client
+}
+
+func (p *clientPool) put(client *contextClient) {
+	client.ctx.ctx.Store(nil)
+	p.pool.Put(client)
+}
+
+// releaseOnClose Body 关闭后将 client 放回，未关闭时 client 不再复用
+type releaseOnClose struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
+
+// newTransport 与 OBS SDK 的默认配置一致：连接与响应头超时 60s，空闲连接 30s，每个 host 1000 个连接，
+// 不压缩，不校验证书（SDK 默认 sslVerify 为 false），不使用代理
+func newTransport() *http.Transport {
+	dialer := &net.Dialer{Timeout: 60 * time.Second}
+	return &http.Transport{
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          1000,
+		MaxIdleConnsPerHost:   1000,
+		ResponseHeaderTimeout: 60 * time.Second,
+		IdleConnTimeout:       30 * time.Second,
+		TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
+		DisableCompression:    true,
+	}
+}
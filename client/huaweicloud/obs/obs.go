@@ -1,28 +1,65 @@
 package obs
 
 import (
+	"context"
+	"errors"
 	"io"
 
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
 	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
 	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const provider = "huaweicloud.obs"
+
 type HuaweiCloudObs struct {
 	Client *obs.ObsClient
+
+	// contextClients 供 *WithContext 方法使用，未通过 NewHuaweiCloudObs 创建时使用 Client
+	contextClients *clientPool
 }
 
 func NewHuaweiCloudObs(accessKeyID, accessKeySecret, endpoint string) (*HuaweiCloudObs, error) {
-
 	client, err := obs.New(accessKeyID, accessKeySecret, endpoint)
 
 	if err != nil {
 		return nil, xerror.Wrap(err)
 	}
 
-	return &HuaweiCloudObs{Client: client}, nil
+	contextClients, err := newClientPool(accessKeyID, accessKeySecret, endpoint)
+	if err != nil {
+		return nil, xerror.Wrap(err)
+	}
+
+	return &HuaweiCloudObs{
+		Client:         client,
+		contextClients: contextClients,
+	}, nil
 }
 
 func (h *HuaweiCloudObs) PutObject(bucketName, objectKey string, data io.Reader) (*obs.PutObjectOutput, error) {
+	return h.putObject(context.Background(), bucketName, objectKey, data, false)
+}
+
+// PutObjectWithContext 同 PutObject，请求随 ctx 取消，并在 ctx 的 trace 中记录 span
+func (h *HuaweiCloudObs) PutObjectWithContext(ctx context.Context, bucketName, objectKey string, data io.Reader) (*obs.PutObjectOutput, error) {
+	return h.putObject(ctx, bucketName, objectKey, data, true)
+}
+
+func (h *HuaweiCloudObs) putObject(ctx context.Context, bucketName, objectKey string, data io.Reader, withContext bool) (resp *obs.PutObjectOutput, err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "PutObject",
+		attribute.String("bucket", bucketName),
+		attribute.String("object.key", objectKey),
+	)
+	defer func() {
+		var statusCode int
+		if resp != nil {
+			statusCode = resp.StatusCode
+		}
+		endSpan(span, statusCode, err)
+	}()
 
 	input := &obs.PutObjectInput{}
 	// 指定存储桶名称
@@ -33,7 +70,13 @@ func (h *HuaweiCloudObs) PutObject(bucketName, objectKey string, data io.Reader)
 	input.Body = data
 
 	// 流式上传本地文件
-	resp, err := h.Client.PutObject(input)
+	if withContext && h.contextClients != nil {
+		client := h.contextClients.get(ctx)
+		defer h.contextClients.put(client)
+		resp, err = client.PutObject(input)
+	} else {
+		resp, err = h.Client.PutObject(input)
+	}
 
 	if err != nil {
 		return nil, xerror.Wrap(err)
@@ -43,12 +86,43 @@ func (h *HuaweiCloudObs) PutObject(bucketName, objectKey string, data io.Reader)
 }
 
 func (h *HuaweiCloudObs) GetObject(bucketName, objectKey string) (*obs.GetObjectOutput, error) {
+	return h.getObject(context.Background(), bucketName, objectKey, false)
+}
+
+// GetObjectWithContext 同 GetObject，请求随 ctx 取消，并在 ctx 的 trace 中记录 span
+// ctx 取消后返回的 Body 也无法继续读取
+func (h *HuaweiCloudObs) GetObjectWithContext(ctx context.Context, bucketName, objectKey string) (*obs.GetObjectOutput, error) {
+	return h.getObject(ctx, bucketName, objectKey, true)
+}
+
+func (h *HuaweiCloudObs) getObject(ctx context.Context, bucketName, objectKey string, withContext bool) (resp *obs.GetObjectOutput, err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "GetObject",
+		attribute.String("bucket", bucketName),
+		attribute.String("object.key", objectKey),
+	)
+	defer func() {
+		var statusCode int
+		if resp != nil {
+			statusCode = resp.StatusCode
+		}
+		endSpan(span, statusCode, err)
+	}()
 
 	input := &obs.GetObjectInput{}
 	input.Bucket = bucketName
 	input.Key = objectKey
 
-	resp, err := h.Client.GetObject(input)
+	if withContext && h.contextClients != nil {
+		client := h.contextClients.get(ctx)
+		resp, err = client.GetObject(input)
+		if err != nil || resp.Body == nil {
+			h.contextClients.put(client)
+		} else {
+			resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: func() { h.contextClients.put(client) }}
+		}
+	} else {
+		resp, err = h.Client.GetObject(input)
+	}
 
 	if err != nil {
 		return nil, xerror.Wrap(err)
@@ -56,3 +130,14 @@ func (h *HuaweiCloudObs) GetObject(bucketName, objectKey string) (*obs.GetObject
 
 	return resp, nil
 }
+
+// endSpan 记录 OBS 的错误码或 HTTP 状态码后结束 span
+func endSpan(span trace.Span, statusCode int, err error) {
+	var obsErr obs.ObsError
+	if errors.As(err, &obsErr) {
+		span.SetAttributes(otelutils.AttrResultCode.String(obsErr.Code), attribute.Int("http.response.status_code", obsErr.StatusCode))
+	} else if statusCode != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+	}
+	otelutils.EndSpan(span, err)
+}
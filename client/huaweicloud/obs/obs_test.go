@@ -0,0 +1,70 @@
+package obs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/xhttp/xhttptest"
+)
+
+func TestPutObjectWithContext(t *testing.T) {
+	srv := xhttptest.NewServer(t)
+	srv.Handle(http.MethodPut, "/bucket/a.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	client, err := NewHuaweiCloudObs("ak", "sk", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.PutObjectWithContext(context.Background(), "bucket", "a.txt", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.PutObjectWithContext(ctx, "bucket", "a.txt", strings.NewReader("hello")); err == nil {
+		t.Fatal("canceled ctx should abort the request")
+	}
+	if n := len(srv.Requests()); n != 1 {
+		t.Fatalf("requests = %d, want 1", n)
+	}
+
+	if _, err := client.PutObject("bucket", "a.txt", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(srv.Requests()); n != 2 {
+		t.Fatalf("requests = %d, want 2", n)
+	}
+}
+
+func TestGetObjectWithContext(t *testing.T) {
+	srv := xhttptest.NewServer(t)
+	srv.Handle(http.MethodGet, "/bucket/a.txt", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	})
+
+	client, err := NewHuaweiCloudObs("ak", "sk", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.GetObjectWithContext(context.Background(), "bucket", "a.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != "hello" {
+			t.Fatalf("body = %q", body)
+		}
+	}
+}
@@ -16,17 +16,23 @@ type DifyChatMessageRequest struct {
 	BaseURL        string                `json:"base_url"`
 	APIKey         string                `json:"api_key"`
 	Files          []DifyChatMessageFile `json:"files"`
+	// Variables 应用定义的输入变量，dify.Client 发送时作为 inputs
+	Variables map[string]any `json:"variables,omitempty"`
 }
 
 type DifyChatMessageFile struct {
 	Type           string `json:"type"`
 	TransferMethod string `json:"transfer_method"`
 	URL            string `json:"url"`
+	// UploadFileID TransferMethod 为 local_file 时使用 dify.Client.UploadFile 返回的文件 id
+	UploadFileID string `json:"upload_file_id,omitempty"`
 }
 
 type DifyChatMessageResponse struct {
 	Event          string `json:"event"`
 	ConversationID string `json:"conversation_id"`
 	Answer         string `json:"answer"`
+	MessageID      string `json:"message_id,omitempty"`
+	TaskID         string `json:"task_id,omitempty"`
 	// Message        string `json:"message"`
 }
@@ -1,13 +1,27 @@
 package models
 
 const (
+	FastGPTChatMessageRoleSystem    = "system"
 	FastGPTChatMessageRoleUser      = "user"
 	FastGPTChatMessageRoleAssistant = "assistant"
+	FastGPTChatMessageRoleTool      = "tool"
+)
+
+// 多模态内容的类型
+const (
+	FastGPTChatMessageContentText     = "text"
+	FastGPTChatMessageContentImageURL = "image_url"
 )
 
 type FastGPTChatRequestMessage struct {
-	Role    string `json:"role"`
+	Role string `json:"role"`
+	// Content 为 string 或 []FastGPTChatMessageContent
 	Content any    `json:"content"`
+	Name    string `json:"name,omitempty"`
+	// ToolCalls 回传 assistant 的工具调用
+	ToolCalls []FastGPTToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID role 为 tool 时对应的工具调用 id
+	ToolCallID string `json:"tool_call_id,omitempty"`
 }
 
 type FastGPTChatMessageContent struct {
@@ -18,11 +32,41 @@ type FastGPTChatMessageContent struct {
 
 type ImageURL struct {
 	URL string `json:"url"`
+	// Detail 图片精度 auto、low、high
+	Detail string `json:"detail,omitempty"`
 }
 
 type FastGPTChatResponseMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role      string            `json:"role"`
+	Content   string            `json:"content"`
+	ToolCalls []FastGPTToolCall `json:"tool_calls,omitempty"`
+}
+
+// FastGPTTool 可供模型调用的工具，Type 目前只支持 function
+type FastGPTTool struct {
+	Type     string             `json:"type"`
+	Function FastGPTFunctionDef `json:"function"`
+}
+
+type FastGPTFunctionDef struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Parameters JSON Schema
+	Parameters any `json:"parameters,omitempty"`
+}
+
+type FastGPTToolCall struct {
+	// Index 流式响应中用于合并同一个工具调用的分片
+	Index    *int                `json:"index,omitempty"`
+	ID       string              `json:"id,omitempty"`
+	Type     string              `json:"type,omitempty"`
+	Function FastGPTFunctionCall `json:"function"`
+}
+
+type FastGPTFunctionCall struct {
+	Name string `json:"name,omitempty"`
+	// Arguments JSON 字符串，流式响应中分片返回
+	Arguments string `json:"arguments,omitempty"`
 }
 
 type FastGPTChatCompletionRequest struct {
@@ -32,17 +76,41 @@ type FastGPTChatCompletionRequest struct {
 	Variables map[string]any              `json:"variables,omitempty"`
 	APIKey    string                      `json:"api_key"`
 	BaseURL   string                      `json:"base_url"`
+
+	// ChatID FastGPT 的会话 id，设置后由 FastGPT 保存上下文
+	ChatID      string        `json:"chatId,omitempty"`
+	Tools       []FastGPTTool `json:"tools,omitempty"`
+	ToolChoice  any           `json:"tool_choice,omitempty"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+}
+
+type FastGPTUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type FastGPTChatCompletionChoice struct {
+	Index        int                        `json:"index"`
+	Message      FastGPTChatResponseMessage `json:"message"`
+	Delta        FastGPTChatResponseMessage `json:"delta"`
+	FinishReason string                     `json:"finish_reason,omitempty"`
 }
 
 type FastGPTChatCompletionResponse struct {
-	ID      string `json:"id"`
-	Choices []struct {
-		Index   int                        `json:"index"`
-		Message FastGPTChatResponseMessage `json:"message"`
-		Delta   FastGPTChatResponseMessage `json:"delta"`
-	} `json:"choices"`
+	ID      string                        `json:"id"`
+	Model   string                        `json:"model,omitempty"`
+	Created int64                         `json:"created,omitempty"`
+	Choices []FastGPTChatCompletionChoice `json:"choices"`
+	Usage   *FastGPTUsage                 `json:"usage,omitempty"`
+	// Error 部分服务在流式响应中以 data 返回错误
+	Error *FastGPTChatCompletionError `json:"error,omitempty"`
 }
 
 type FastGPTChatCompletionError struct {
 	Message string `json:"message"`
+	Type    string `json:"type,omitempty"`
+	// Code OpenAI 为字符串，FastGPT 为数字
+	Code any `json:"code,omitempty"`
 }
@@ -3,14 +3,15 @@ package resend
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"time"
-
-	"bytes"
 	"net/http"
+	"time"
 
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/xhttp"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type EmailMessage struct {
@@ -66,25 +67,26 @@ func (c *ResendClient) BatchSendLoop(onError func(error)) {
 			})
 		}
 		if len(batch) > 0 {
-			body, _ := json.Marshal(batch)
-			req, err := http.NewRequest("POST", "https://api.resend.com/emails/batch", bytes.NewBuffer(body))
-			if err != nil {
+			if err := c.sendBatch(context.Background(), batch); err != nil {
 				onError(err)
-				continue
 			}
-			req.Header.Set("Authorization", "Bearer "+c.opts.APIKey)
-			req.Header.Set("Content-Type", "application/json")
-
-			resp, err := http.DefaultClient.Do(req)
-			if err != nil {
-				onError(err)
-				continue
-			}
-			if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
-				bodyBytes, _ := io.ReadAll(resp.Body)
-				onError(fmt.Errorf("resend batch send failed, status: %d, body: %s", resp.StatusCode, string(bodyBytes)))
-			}
-			resp.Body.Close()
 		}
 	}
 }
+
+// sendBatch 调用批量发送接口，每批记录一个 span
+func (c *ResendClient) sendBatch(ctx context.Context, batch []map[string]interface{}) (err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "BatchSend", attribute.Int("batch.size", len(batch)))
+	defer func() { otelutils.EndSpan(span, err) }()
+
+	_, err = xhttp.DoJSON[[]map[string]interface{}, json.RawMessage](ctx, c.opts.httpClient, http.MethodPost,
+		c.opts.BaseURL+"emails/batch", batch, xhttp.WithBearerToken(c.opts.APIKey))
+	var httpErr *xhttp.HTTPError
+	if errors.As(err, &httpErr) {
+		span.SetAttributes(attribute.Int("http.response.status_code", httpErr.StatusCode))
+	}
+	if err != nil {
+		return fmt.Errorf("resend batch send failed: %w", err)
+	}
+	return nil
+}
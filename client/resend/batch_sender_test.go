@@ -22,11 +22,11 @@ func TestEnqueueEmailConcurrentLatency(t *testing.T) {
 	from := "test@futurx.cn"
 	testTo := "1148562789@qq.com"
 	concurrent := 100
-	apiKey := "re_gwW8vqKh_7UKydcRWVAhhN6pLx4rShCSU"
+	apiKey := os.Getenv("RESEND_API_KEY")
 
 	rdb := redis.NewClient(&redis.Options{
-		Addr:     "139.224.251.109:6379",
-		Password: "testAdmin123",
+		Addr:     os.Getenv("REDIS_ADDR"),
+		Password: os.Getenv("REDIS_PASSWORD"),
 		DB:       0,
 	})
 	defer rdb.Close()
@@ -1,6 +1,8 @@
 package resend
 
 import (
+	"net/http"
+
 	"github.com/redis/go-redis/v9"
 )
 
@@ -9,7 +11,10 @@ type Options struct {
 	From               string `json:"from" yaml:"from"`
 	VerifyCodeTemplate string `json:"verify_code_template" yaml:"verify_code_template"`
 	VerifyCodeSubject  string `json:"verify_code_subject" yaml:"verify_code_subject"`
-	rdb                *redis.Client
+	// BaseURL 默认 https://api.resend.com/
+	BaseURL    string `json:"base_url" yaml:"base_url"`
+	httpClient *http.Client
+	rdb        *redis.Client
 }
 
 type Option func(*Options)
@@ -44,3 +49,17 @@ func WithRedis(rdb *redis.Client) Option {
 		o.rdb = rdb
 	}
 }
+
+// WithBaseURL 设置接口地址，用于代理或测试
+func WithBaseURL(baseURL string) Option {
+	return func(o *Options) {
+		o.BaseURL = baseURL
+	}
+}
+
+// WithHTTPClient 设置发送请求的 http.Client，默认 http.DefaultClient
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *Options) {
+		o.httpClient = client
+	}
+}
@@ -1,12 +1,20 @@
 package resend
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
 	"github.com/redis/go-redis/v9"
 	"github.com/resend/resend-go/v2"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+const provider = "resend"
+
 type ResendClient struct {
 	Client *resend.Client
 	opts   Options
@@ -19,11 +27,32 @@ func NewResendClient(options ...Option) *ResendClient {
 	for _, option := range options {
 		option(&opts)
 	}
-	client := resend.NewClient(opts.APIKey)
+	if opts.BaseURL == "" {
+		opts.BaseURL = "https://api.resend.com/"
+	}
+	if !strings.HasSuffix(opts.BaseURL, "/") {
+		opts.BaseURL += "/"
+	}
+	if opts.httpClient == nil {
+		opts.httpClient = http.DefaultClient
+	}
+
+	client := resend.NewCustomClient(opts.httpClient, opts.APIKey)
+	if baseURL, err := url.Parse(opts.BaseURL); err == nil {
+		client.BaseURL = baseURL
+	}
 	return &ResendClient{Client: client, opts: opts, rdb: opts.rdb}
 }
 
 func (c *ResendClient) SendEmail(tos []string, subject string, html string) (string, error) {
+	return c.SendEmailWithContext(context.Background(), tos, subject, html)
+}
+
+// SendEmailWithContext 同 SendEmail，请求随 ctx 取消，并在 ctx 的 trace 中记录 span
+func (c *ResendClient) SendEmailWithContext(ctx context.Context, tos []string, subject string, html string) (id string, err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "SendEmail", attribute.Int("email.recipients", len(tos)))
+	defer func() { otelutils.EndSpan(span, err) }()
+
 	params := &resend.SendEmailRequest{
 		From:    c.opts.From,
 		To:      tos,
@@ -31,11 +60,12 @@ func (c *ResendClient) SendEmail(tos []string, subject string, html string) (str
 		Subject: subject,
 	}
 
-	sent, err := c.Client.Emails.Send(params)
+	sent, err := c.Client.Emails.SendWithContext(ctx, params)
 	if err != nil {
 		return "", err
 	}
 
+	span.SetAttributes(attribute.String("email.id", sent.Id))
 	return sent.Id, nil
 }
 
@@ -43,3 +73,9 @@ func (c *ResendClient) SendVerifyCode(to string, code string) (string, error) {
 	html := fmt.Sprintf(c.opts.VerifyCodeTemplate, code)
 	return c.SendEmail([]string{to}, c.opts.VerifyCodeSubject, html)
 }
+
+// SendVerifyCodeWithContext 同 SendVerifyCode，请求随 ctx 取消，并在 ctx 的 trace 中记录 span
+func (c *ResendClient) SendVerifyCodeWithContext(ctx context.Context, to string, code string) (string, error) {
+	html := fmt.Sprintf(c.opts.VerifyCodeTemplate, code)
+	return c.SendEmailWithContext(ctx, []string{to}, c.opts.VerifyCodeSubject, html)
+}
@@ -1,12 +1,62 @@
 package resend
 
 import (
+	"encoding/json"
+	"net/http"
+	"os"
 	"testing"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/xhttp/xhttptest"
 )
 
 func TestSendVerifyCode(t *testing.T) {
+	srv := xhttptest.NewServer(t)
+	srv.JSON(http.MethodPost, "/emails", http.StatusOK, map[string]string{"id": "email-1"})
+
+	client := NewResendClient(
+		WithAPIKey("re_test"),
+		WithBaseURL(srv.URL),
+		WithFrom("verify@mail.futurx.cn"),
+		WithVerifyCodeTemplate("您的验证码是：%s"),
+		WithVerifyCodeSubject("注册验证码"),
+	)
+
+	id, err := client.SendVerifyCode("lemon.yan@futurx.cn", "123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "email-1" {
+		t.Fatalf("id = %q", id)
+	}
+
+	req := srv.LastRequest()
+	if req.Header.Get("Authorization") != "Bearer re_test" {
+		t.Fatalf("Authorization = %q", req.Header.Get("Authorization"))
+	}
+	var sent struct {
+		From    string   `json:"from"`
+		To      []string `json:"to"`
+		Subject string   `json:"subject"`
+		Html    string   `json:"html"`
+	}
+	if err := json.Unmarshal(req.Body, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if sent.To[0] != "lemon.yan@futurx.cn" || sent.Subject != "注册验证码" || sent.Html != "您的验证码是：123456" {
+		t.Fatalf("sent = %+v", sent)
+	}
+}
+
+// TestSendVerifyCodeCassette 回放 testdata/send_verify_code.json
+// 该 cassette 是按 Resend 文档手写的合成数据，并非真实录制，响应只包含 Content-Type 与 id
+// 回放按方法、脱敏后的 URL 与请求体匹配（BodyMatcher），Authorization 等头部不参与匹配
+// 设置 XHTTPTEST_RECORD=1 与 RESEND_API_KEY 可替换为真实录制
+func TestSendVerifyCodeCassette(t *testing.T) {
+	rec := xhttptest.NewRecorder(t, "testdata/send_verify_code.json",
+		xhttptest.WithMatcher(xhttptest.BodyMatcher(xhttptest.DefaultScrubQuery)))
 	client := NewResendClient(
-		WithAPIKey("re_Gxv4pr1Z_6crPeLfWx9jpy3PiMgQ8GZP7"),
+		WithAPIKey(os.Getenv("RESEND_API_KEY")),
+		WithHTTPClient(rec.Client()),
 		WithFrom("verify@mail.futurx.cn"),
 		WithVerifyCodeTemplate("您的验证码是：%s"),
 		WithVerifyCodeSubject("注册验证码"),
@@ -16,5 +66,7 @@ func TestSendVerifyCode(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	t.Log(id)
+	if id == "" {
+		t.Fatal("id should not be empty")
+	}
 }
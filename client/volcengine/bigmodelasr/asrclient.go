@@ -7,9 +7,11 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
 	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -115,9 +117,16 @@ func (c *AsrWsClient) constructFullRequest(audio *AudioSettings) []byte {
 	return gzData
 }
 
-func (c *AsrWsClient) Connect(ctx context.Context, audioSettings *AudioSettings) (*AsrWsSender, error) {
-	headers := http.Header{}
+// Connect 建立连接并发送初始请求，握手过程记录为一个 span
+func (c *AsrWsClient) Connect(ctx context.Context, audioSettings *AudioSettings) (sender *AsrWsSender, err error) {
 	connectID := uuid.New().String()
+	ctx, span := otelutils.StartClientSpan(ctx, "volcengine.asr", "Connect",
+		attribute.String("asr.resource_id", c.opts.ResourceID),
+		attribute.String("asr.connect_id", connectID),
+	)
+	defer func() { otelutils.EndSpan(span, err) }()
+
+	headers := http.Header{}
 	headers.Add("X-Api-Connect-Id", connectID)
 	headers.Add("X-Api-Resource-Id", c.opts.ResourceID)
 	headers.Add("X-Api-App-Key", c.opts.AppKey)
@@ -130,6 +139,7 @@ func (c *AsrWsClient) Connect(ctx context.Context, audioSettings *AudioSettings)
 		var responseBody string
 		if resp != nil {
 			statusCode = resp.StatusCode
+			span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
 			if resp.Body != nil {
 				bodyBytes, readErr := io.ReadAll(resp.Body)
 				if readErr == nil {
@@ -166,6 +176,7 @@ func (c *AsrWsClient) Connect(ctx context.Context, audioSettings *AudioSettings)
 	}
 
 	c.opts.Logger.Infof(ctx, "connected to ASR server, asr_log_id: %s", parsedResp.PayloadMsg.Result.Additions.LogID)
+	span.SetAttributes(attribute.String("asr.log_id", parsedResp.PayloadMsg.Result.Additions.LogID))
 
 	asrWsSender := &AsrWsSender{
 		conn: conn,
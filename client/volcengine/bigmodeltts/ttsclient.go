@@ -12,8 +12,10 @@ import (
 	"github.com/gorilla/websocket"
 
 	"github.com/Yet-Another-AI-Project/kiwi-lib/logger"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
 	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type TTSWsClient struct {
@@ -78,7 +80,8 @@ func DefaultAudioSettings() *AudioSettings {
 }
 
 // Connect establishes a new websocket connection and returns sender and receiver
-func (c *TTSWsClient) Connect(ctx context.Context, audioSettings *AudioSettings) (*TTSWsSender, *TTSReceiver, error) {
+// 握手过程记录为一个 span
+func (c *TTSWsClient) Connect(ctx context.Context, audioSettings *AudioSettings) (sender *TTSWsSender, receiver *TTSReceiver, err error) {
 	if audioSettings == nil {
 		audioSettings = DefaultAudioSettings()
 	}
@@ -88,6 +91,12 @@ func (c *TTSWsClient) Connect(ctx context.Context, audioSettings *AudioSettings)
 		audioSettings.ResourceID = c.opts.DefaultResourceID
 	}
 
+	ctx, span := otelutils.StartClientSpan(ctx, "volcengine.tts", "Connect",
+		attribute.String("tts.resource_id", audioSettings.ResourceID),
+		attribute.String("tts.speaker", audioSettings.Speaker),
+	)
+	defer func() { otelutils.EndSpan(span, err) }()
+
 	c.logger.Infof(ctx, "TTS connection, audio_settings: %+v", audioSettings)
 
 	// Connect to websocket
@@ -105,7 +114,7 @@ func (c *TTSWsClient) Connect(ctx context.Context, audioSettings *AudioSettings)
 	c.logger.Infof(ctx, "TTS connection established, endpoint: %s, resource_id: %s", c.opts.Endpoint, audioSettings.ResourceID)
 
 	// Create sender and receiver
-	sender := &TTSWsSender{
+	sender = &TTSWsSender{
 		conn:          conn,
 		protocol:      c.protocol,
 		opts:          c.opts,
@@ -114,7 +123,7 @@ func (c *TTSWsClient) Connect(ctx context.Context, audioSettings *AudioSettings)
 		speaker:       audioSettings.Speaker,
 	}
 
-	receiver := NewTTSReceiver(conn, c.protocol, c.logger)
+	receiver = NewTTSReceiver(conn, c.protocol, c.logger)
 
 	return sender, receiver, nil
 }
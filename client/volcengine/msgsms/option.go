@@ -9,6 +9,7 @@ type Options struct {
 	TemplateParam string `json:"template_param" yaml:"template_param"` // 当指定的短信模板（TemplateID）存在变量时，您需要设置变量的实际值。支持传入一个或多个参数，格式示例：{"code1":"1234", "code2":"5678"}
 	DefaultScene  string `json:"default_scene" yaml:"default_scene"`   // 默认使用场景
 	Tag           string `json:"tag" yaml:"tag"`                       // 透传字段
+	Endpoint      string `json:"endpoint" yaml:"endpoint"`             // 服务地址，如 https://sms.volcengineapi.com，为空时使用 SDK 默认地址
 }
 
 type Option func(*Options)
@@ -60,3 +61,9 @@ func WithTag(tag string) Option {
 		o.Tag = tag
 	}
 }
+
+func WithEndpoint(endpoint string) Option {
+	return func(o *Options) {
+		o.Endpoint = endpoint
+	}
+}
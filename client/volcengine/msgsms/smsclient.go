@@ -1,16 +1,37 @@
 package msgsms
 
 import (
-	"github.com/volcengine/volc-sdk-golang/service/sms"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
 	"strings"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
+	"github.com/volcengine/volc-sdk-golang/service/sms"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const provider = "volcengine.sms"
+
 type SmsClient interface {
 	SendVerifyCode(phone, templateId string) (*sms.SmsResponse, error)
 	CheckVerifyCode(phone, code string) (bool, error)
 	SendSms(phones []string, templateId, templateParam string) (*sms.SmsResponse, int, error)
 }
 
+// SmsClientWithContext 请求随 ctx 取消，并在 ctx 的 trace 中记录 span
+// NewSmsClient 返回的 client 实现了该接口
+type SmsClientWithContext interface {
+	SmsClient
+	SendVerifyCodeWithContext(ctx context.Context, phone, templateId string) (*sms.SmsResponse, error)
+	CheckVerifyCodeWithContext(ctx context.Context, phone, code string) (bool, error)
+	SendSmsWithContext(ctx context.Context, phones []string, templateId, templateParam string) (*sms.SmsResponse, int, error)
+}
+
+var _ SmsClientWithContext = (*volcanoClient)(nil)
+
 type volcanoClient struct {
 	opts     Options
 	instance *sms.SMS
@@ -24,9 +45,13 @@ func NewSmsClient(options ...Option) *volcanoClient {
 	}
 
 	// 初始化火山引擎实例
-	instance := sms.DefaultInstance
+	instance := sms.NewInstance()
 	instance.Client.SetAccessKey(opts.AccessKey)
 	instance.Client.SetSecretKey(opts.SecretKey)
+	if u, err := url.Parse(opts.Endpoint); err == nil && u.Host != "" {
+		instance.Client.ServiceInfo.Scheme = u.Scheme
+		instance.Client.ServiceInfo.Host = u.Host
+	}
 
 	return &volcanoClient{
 		opts:     opts,
@@ -36,6 +61,15 @@ func NewSmsClient(options ...Option) *volcanoClient {
 
 // 发送验证码
 func (c *volcanoClient) SendVerifyCode(phone, templateId string) (*sms.SmsResponse, error) {
+	return c.SendVerifyCodeWithContext(context.Background(), phone, templateId)
+}
+
+// SendVerifyCodeWithContext 同 SendVerifyCode，请求随 ctx 取消，并在 ctx 的 trace 中记录 span
+func (c *volcanoClient) SendVerifyCodeWithContext(ctx context.Context, phone, templateId string) (resp *sms.SmsResponse, err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "SendVerifyCode", attribute.String("sms.template_id", templateId))
+	var status int
+	defer func() { endSpan(span, resp, status, err) }()
+
 	req := &sms.SmsVerifyCodeRequest{
 		SmsAccount:  c.opts.SmsAccount,
 		Sign:        c.opts.SignName,
@@ -46,8 +80,8 @@ func (c *volcanoClient) SendVerifyCode(phone, templateId string) (*sms.SmsRespon
 		CodeType:    6, // 验证码类型4/6/8位
 	}
 
-	resp, _, err := c.instance.SendVerifyCode(req)
-	if err != nil {
+	resp = new(sms.SmsResponse)
+	if status, err = c.call(ctx, "SendSmsVerifyCode", req, resp); err != nil {
 		return nil, err
 	}
 	return resp, nil
@@ -55,6 +89,22 @@ func (c *volcanoClient) SendVerifyCode(phone, templateId string) (*sms.SmsRespon
 
 // 校验验证码
 func (c *volcanoClient) CheckVerifyCode(phone, code string) (bool, error) {
+	return c.CheckVerifyCodeWithContext(context.Background(), phone, code)
+}
+
+// CheckVerifyCodeWithContext 同 CheckVerifyCode，请求随 ctx 取消，并在 ctx 的 trace 中记录 span
+func (c *volcanoClient) CheckVerifyCodeWithContext(ctx context.Context, phone, code string) (ok bool, err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "CheckVerifyCode")
+	resp := new(sms.CheckSmsVerifyCodeResponse)
+	var status int
+	defer func() {
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if err == nil {
+			span.SetAttributes(otelutils.AttrResultCode.String(resp.Result))
+		}
+		otelutils.EndSpan(span, err)
+	}()
+
 	req := &sms.CheckSmsVerifyCodeRequest{
 		SmsAccount:  c.opts.SmsAccount,
 		PhoneNumber: phone,
@@ -62,8 +112,7 @@ func (c *volcanoClient) CheckVerifyCode(phone, code string) (bool, error) {
 		Code:        code,
 	}
 
-	resp, _, err := c.instance.CheckVerifyCode(req)
-	if err != nil {
+	if status, err = c.call(ctx, "CheckSmsVerifyCode", req, resp); err != nil {
 		return false, err
 	}
 
@@ -72,6 +121,17 @@ func (c *volcanoClient) CheckVerifyCode(phone, code string) (bool, error) {
 }
 
 func (c *volcanoClient) SendSms(phones []string, templateID, templateParam string) (*sms.SmsResponse, int, error) {
+	return c.SendSmsWithContext(context.Background(), phones, templateID, templateParam)
+}
+
+// SendSmsWithContext 同 SendSms，请求随 ctx 取消，并在 ctx 的 trace 中记录 span
+func (c *volcanoClient) SendSmsWithContext(ctx context.Context, phones []string, templateID, templateParam string) (resp *sms.SmsResponse, status int, err error) {
+	ctx, span := otelutils.StartClientSpan(ctx, provider, "SendSms",
+		attribute.String("sms.template_id", templateID),
+		attribute.Int("sms.recipients", len(phones)),
+	)
+	defer func() { endSpan(span, resp, status, err) }()
+
 	req := &sms.SmsRequest{
 		SmsAccount:    c.opts.SmsAccount,
 		Sign:          c.opts.SignName,
@@ -80,5 +140,41 @@ func (c *volcanoClient) SendSms(phones []string, templateID, templateParam strin
 		PhoneNumbers:  strings.Join(phones, ","), //要求使用‘,’分隔
 	}
 
-	return c.instance.Send(req)
+	resp = new(sms.SmsResponse)
+	if status, err = c.call(ctx, "SendSms", req, resp); err != nil {
+		return nil, status, err
+	}
+	return resp, status, nil
+}
+
+// call 与 SDK 的 smsHandler 行为一致（5xx 时重试一次），但请求随 ctx 取消
+func (c *volcanoClient) call(ctx context.Context, api string, req, resp any) (int, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	respBody, status, err := c.instance.Client.CtxJson(ctx, api, nil, string(body))
+	if err != nil {
+		return status, err
+	}
+	if status >= 500 {
+		if respBody, status, err = c.instance.Client.CtxJson(ctx, api, nil, string(body)); err != nil {
+			return status, err
+		}
+	}
+
+	if err := json.Unmarshal(respBody, resp); err != nil {
+		return status, err
+	}
+	return status, nil
+}
+
+// endSpan 记录火山引擎返回的错误码与 HTTP 状态码后结束 span
+func endSpan(span trace.Span, resp *sms.SmsResponse, status int, err error) {
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if resp != nil && resp.ResponseMetadata.Error != nil {
+		span.SetAttributes(otelutils.AttrResultCode.String(resp.ResponseMetadata.Error.Code))
+	}
+	otelutils.EndSpan(span, err)
 }
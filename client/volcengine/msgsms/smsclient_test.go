@@ -1,58 +1,105 @@
 package msgsms
 
 import (
-	"fmt"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
 	"testing"
-)
 
-type sendSmsTemplateParam struct {
-	Code string `json:"code"`
-}
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/xhttp/xhttptest"
+	"github.com/volcengine/volc-sdk-golang/service/sms"
+)
 
-func TestSmsClient(t *testing.T) {
+func newTestClient(t *testing.T) (*volcanoClient, *xhttptest.Server) {
+	srv := xhttptest.NewServer(t)
 	client := NewSmsClient(
-		WithAccessKey(""),
-		WithSecretKey(""),
+		WithAccessKey("test-ak"),
+		WithSecretKey("test-sk"),
 		WithSmsAccount("83883d4e"),
 		WithSignName("向量涌现"),
-		//WithTemplateID("ST_8393772b"),
 		WithDefaultScene("注册验证码"),
+		WithEndpoint(srv.URL),
 	)
+	return client, srv
+}
 
-	res, err := client.SendVerifyCode("17689325441", "ST_838dbb48")
+func TestSmsClient(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.JSON(http.MethodPost, "/", http.StatusOK, map[string]any{
+		"ResponseMetadata": map[string]any{"RequestId": "req-1"},
+		"Result":           map[string]any{"MessageID": []string{"msg-1"}},
+	})
+
+	res, err := client.SendVerifyCode("17600000000", "ST_838dbb48")
 	if err != nil {
 		t.Fatal(err)
 	}
-	t.Logf("%+v", res)
-
-	//response, err := client.SendVerifyCode("19821136572", "注册验证码", "ST_838dbb48", 600, 6)
-	//
-	//if err != nil {
-	//	t.Fatal(err)
-	//}
-	//t.Logf("%+v", response)
-	//
-	//success, err := client.CheckVerifyCode("19821136572", "注册验证码", "033222")
-	//
-	//if err != nil {
-	//	t.Fatal(err)
-	//}
-	//t.Logf("%+v", success)
+	if len(res.Result.MessageID) != 1 || res.Result.MessageID[0] != "msg-1" {
+		t.Fatalf("unexpected result: %+v", res.Result)
+	}
 
+	req := srv.LastRequest()
+	if !strings.Contains(req.URL, "Action=SendSmsVerifyCode") {
+		t.Fatalf("unexpected url: %s", req.URL)
+	}
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "HMAC-SHA256 Credential=test-ak/") {
+		t.Fatalf("request not signed: %q", req.Header.Get("Authorization"))
+	}
+	var body sms.SmsVerifyCodeRequest
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.PhoneNumber != "17600000000" || body.TemplateID != "ST_838dbb48" || body.Scene != "注册验证码" {
+		t.Fatalf("unexpected body: %+v", body)
+	}
 }
+
 func TestCheckVerifyCode(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.JSON(http.MethodPost, "/", http.StatusOK, map[string]any{
+		"ResponseMetadata": map[string]any{"RequestId": "req-1"},
+		"Result":           "0",
+	})
+	srv.JSON(http.MethodPost, "/", http.StatusOK, map[string]any{
+		"ResponseMetadata": map[string]any{"RequestId": "req-2"},
+		"Result":           "2",
+	})
+
+	ok, err := client.CheckVerifyCode("17600000000", "682781")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("expected code to be valid")
+	}
+	if !strings.Contains(srv.LastRequest().URL, "Action=CheckSmsVerifyCode") {
+		t.Fatalf("unexpected url: %s", srv.LastRequest().URL)
+	}
+
+	ok, err = client.CheckVerifyCode("17600000000", "682781")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("expired code should not be valid")
+	}
+}
+
+// TestCheckVerifyCodeLive 调用真实服务，需设置 LOCAL_TEST 与火山引擎凭据
+func TestCheckVerifyCodeLive(t *testing.T) {
+	if os.Getenv("LOCAL_TEST") == "" {
+		t.Skip("set LOCAL_TEST to run against volcengine")
+	}
 	client := NewSmsClient(
-		WithAccessKey("AKLTNGZmMWJlNzE0OTkxNDI0NThhOWEzNGZjZDAyZjgzZDA"),
-		WithSecretKey("WlRkbVkySTFZVGhsTXpnNE5HTTNaRGhoTVRKak1tVmpORGsyT0dJd1l6VQ=="),
-		WithSmsAccount("83883d4e"),
-		WithSignName("向量涌现"),
+		WithAccessKey(os.Getenv("VOLC_ACCESS_KEY")),
+		WithSecretKey(os.Getenv("VOLC_SECRET_KEY")),
+		WithSmsAccount(os.Getenv("VOLC_SMS_ACCOUNT")),
 		WithDefaultScene("注册验证码"),
 	)
-	ok, err := client.CheckVerifyCode("17689325441", "682781")
+	ok, err := client.CheckVerifyCode(os.Getenv("VOLC_SMS_PHONE"), os.Getenv("VOLC_SMS_CODE"))
 	if err != nil {
-		fmt.Println(err)
-	}
-	if !ok {
-		fmt.Println("11校验失败")
+		t.Fatal(err)
 	}
+	t.Logf("verify result: %v", ok)
 }
@@ -20,6 +20,8 @@ const (
 )
 
 // Client 封装了与微信小程序 API 的交互
+// 请求没有使用 xhttp.DoJSON：getwxacode 成功时返回图片二进制，出错时才返回 JSON；
+// 三方 token 接口只接受 200，且需要在业务错误时记录原始响应体
 type Client struct {
 	httpClient *http.Client
 	logger     logger.ILogger
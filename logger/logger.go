@@ -2,12 +2,21 @@ package logger
 
 import (
 	"context"
+	"fmt"
 	"os"
 
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+const (
+	// FieldTraceID 日志中 trace id 的字段名
+	FieldTraceID = "trace_id"
+	// FieldAlert 标记该条日志需要触发告警的字段名
+	FieldAlert = "alert"
+)
+
 type ILogger interface {
 	Debugf(ctx context.Context, msg string, args ...interface{})
 	Infof(ctx context.Context, msg string, args ...interface{})
@@ -19,38 +28,78 @@ type ILogger interface {
 
 type Logger struct {
 	level         string
+	sampling      *SamplingConfig
+	dedupe        *DedupeConfig
+	cores         []zapcore.Core
 	DefaultLogger *zap.Logger
 }
 
 func (log *Logger) Debugf(ctx context.Context, msg string, args ...interface{}) {
-	log.DefaultLogger.Sugar().Debugf(msg, args...)
+	log.logf(ctx, zap.DebugLevel, msg, args)
 }
 
 func (log *Logger) Infof(ctx context.Context, msg string, args ...interface{}) {
-	log.DefaultLogger.Sugar().Infof(msg, args...)
+	log.logf(ctx, zap.InfoLevel, msg, args)
 }
 
 func (log *Logger) Warnf(ctx context.Context, msg string, args ...interface{}) {
-	log.DefaultLogger.Sugar().Warnf(msg, args...)
+	log.logf(ctx, zap.WarnLevel, msg, args)
 }
 
 func (log *Logger) Errorf(ctx context.Context, msg string, args ...interface{}) {
-	log.DefaultLogger.Sugar().Errorf(msg, args...)
+	log.logf(ctx, zap.ErrorLevel, msg, args)
 }
 
 func (log *Logger) DPanicf(ctx context.Context, msg string, args ...interface{}) {
-	log.DefaultLogger.Sugar().DPanicf(msg, args...)
+	log.logf(ctx, zap.DPanicLevel, msg, args)
 }
 
 func (log *Logger) Panicf(ctx context.Context, msg string, args ...interface{}) {
-	log.DefaultLogger.Sugar().Panicf(msg, args...)
+	log.logf(ctx, zap.PanicLevel, msg, args)
 }
 
 func (log *Logger) Fatalf(ctx context.Context, msg string, args ...interface{}) {
-	log.DefaultLogger.Sugar().Fatalf(msg, args...)
+	log.logf(ctx, zap.FatalLevel, msg, args)
 }
 
-func newDefaultLogger(level string) (*zap.Logger, error) {
+// logf 先用未格式化的模板做 Check，采样与去重按模板（即调用点）计数，
+// 通过后再格式化消息，被丢弃的日志不会产生 Sprintf 开销
+func (log *Logger) logf(ctx context.Context, level zapcore.Level, msg string, args []interface{}) {
+	ce := log.DefaultLogger.Check(level, msg)
+	if ce == nil {
+		return
+	}
+
+	if len(args) > 0 {
+		ce.Message = fmt.Sprintf(msg, args...)
+	}
+	ce.Write(contextFields(ctx)...)
+}
+
+// contextFields 从 context 中提取需要附加到日志上的字段
+func contextFields(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+
+	var fields []zap.Field
+	if traceID := otelutils.GetTraceID(ctx); traceID != "" {
+		fields = append(fields, zap.String(FieldTraceID, traceID))
+	}
+	if alert, ok := ctx.Value(contextKeyAlert{}).(bool); ok && alert {
+		fields = append(fields, zap.Bool(FieldAlert, true))
+	}
+	return fields
+}
+
+type contextKeyAlert struct{}
+
+// WithAlert 标记 ctx 下输出的日志需要触发告警，不论日志级别
+func WithAlert(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKeyAlert{}, true)
+}
+
+func newDefaultLogger(level string, sampling *SamplingConfig, dedupe *DedupeConfig, cores []zapcore.Core) (*zap.Logger, error) {
 
 	pe := zap.NewProductionEncoderConfig()
 	pe.EncodeTime = zapcore.ISO8601TimeEncoder
@@ -74,6 +123,19 @@ func newDefaultLogger(level string) (*zap.Logger, error) {
 
 	core := zapcore.NewCore(encoder, syncer, zap.NewAtomicLevelAt(zaplevel))
 
+	if len(cores) > 0 {
+		core = zapcore.NewTee(append([]zapcore.Core{core}, cores...)...)
+	}
+
+	if sampling != nil {
+		core = newSamplerCore(core, *sampling)
+	}
+
+	// 去重在采样之前判断，被去重的日志不占用采样配额
+	if dedupe != nil {
+		core = newDedupeCore(core, *dedupe)
+	}
+
 	logger := zap.New(core, zap.WithCaller(false))
 
 	zap.ReplaceGlobals(logger)
@@ -89,6 +151,28 @@ func WithLevel(level string) Option {
 	}
 }
 
+// WithSampling 开启日志采样，见 SamplingConfig
+func WithSampling(config SamplingConfig) Option {
+	return func(logger *Logger) {
+		logger.sampling = &config
+	}
+}
+
+// WithDedupe 开启同一调用点重复日志的去重，见 DedupeConfig
+func WithDedupe(config DedupeConfig) Option {
+	return func(logger *Logger) {
+		logger.dedupe = &config
+	}
+}
+
+// WithCores 追加额外的 zapcore.Core（如告警），与标准输出并行写入
+// 额外的 core 同样受采样与去重约束
+func WithCores(cores ...zapcore.Core) Option {
+	return func(logger *Logger) {
+		logger.cores = append(logger.cores, cores...)
+	}
+}
+
 func NewLogger(opts ...Option) (*Logger, error) {
 	logger := &Logger{}
 
@@ -96,7 +180,7 @@ func NewLogger(opts ...Option) (*Logger, error) {
 		opt(logger)
 	}
 
-	defaultLogger, err := newDefaultLogger(logger.level)
+	defaultLogger, err := newDefaultLogger(logger.level, logger.sampling, logger.dedupe, logger.cores)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,300 @@
+package logger
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	dropReasonSampled = "sampled"
+	dropReasonDedupe  = "dedupe"
+
+	// dedupeMaxSites 去重表的上限，超过时先清理已过期的调用点，仍超过时淘汰最早的调用点
+	dedupeMaxSites = 4096
+)
+
+// SamplingConfig 日志采样配置
+// 每个 Tick 周期内，同一 level + 消息模板的日志先输出 First 条，之后每 Thereafter 条输出一条
+type SamplingConfig struct {
+	Tick       time.Duration
+	First      int
+	Thereafter int
+}
+
+// DedupeConfig 重复日志去重配置
+// Level 启用的日志在 Window 内同一调用点只输出一条，窗口结束后补发一条携带 suppressed_count 字段的日志
+// Level 为空时只对 ErrorLevel 及以上去重，也可设置为 zapcore.WarnLevel 等
+// 调用点按调用 Logger 或 zap 的代码位置区分，与消息内容无关
+type DedupeConfig struct {
+	Window time.Duration
+	Level  zapcore.LevelEnabler
+}
+
+var (
+	droppedCounterOnce sync.Once
+	droppedCounter     metric.Int64Counter
+)
+
+// recordDropped 记录被丢弃的日志行数，指标名 log.dropped
+func recordDropped(level zapcore.Level, reason string) {
+	droppedCounterOnce.Do(func() {
+		counter, err := otel.Meter("kiwi-lib/logger").Int64Counter(
+			"log.dropped",
+			metric.WithDescription("Number of log lines dropped by sampling or dedupe"),
+			metric.WithUnit("{line}"),
+		)
+		if err == nil {
+			droppedCounter = counter
+		}
+	})
+
+	if droppedCounter == nil {
+		return
+	}
+
+	droppedCounter.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.String("level", level.String()),
+		attribute.String("reason", reason),
+	))
+}
+
+func newSamplerCore(core zapcore.Core, config SamplingConfig) zapcore.Core {
+	if config.Tick <= 0 {
+		config.Tick = time.Second
+	}
+
+	return zapcore.NewSamplerWithOptions(core, config.Tick, config.First, config.Thereafter,
+		zapcore.SamplerHook(func(ent zapcore.Entry, dec zapcore.SamplingDecision) {
+			if dec&zapcore.LogDropped > 0 {
+				recordDropped(ent.Level, dropReasonSampled)
+			}
+		}),
+	)
+}
+
+type dedupeSite struct {
+	windowStart time.Time
+	suppressed  int64
+	// last 与 core 用于窗口结束后补发汇总日志
+	last  zapcore.Entry
+	core  zapcore.Core
+	timer *time.Timer
+	// gen 每次重置时递增，避免过期的定时器补发新窗口的汇总
+	gen uint64
+}
+
+// dedupeSummary 被抑制日志的汇总，在锁外写出
+type dedupeSummary struct {
+	ent        zapcore.Entry
+	core       zapcore.Core
+	suppressed int64
+}
+
+func (s dedupeSummary) write() {
+	s.ent.Time = time.Now()
+	if ce := s.core.Check(s.ent, nil); ce != nil {
+		ce.Write(zap.Int64("suppressed_count", s.suppressed))
+	}
+}
+
+type dedupeState struct {
+	mu    sync.Mutex
+	sites map[string]*dedupeSite
+}
+
+// allow 判断调用点在当前窗口内是否允许输出，返回上一个窗口尚未汇总的抑制条数
+// 被抑制时登记定时器，窗口结束后即使该调用点不再输出也会补发一条汇总日志
+func (s *dedupeState) allow(key string, ent zapcore.Entry, core zapcore.Core, window time.Duration) (int64, bool) {
+	var evicted []dedupeSummary
+	defer func() {
+		for _, summary := range evicted {
+			summary.write()
+		}
+	}()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := ent.Time
+	site, ok := s.sites[key]
+	if !ok {
+		if len(s.sites) >= dedupeMaxSites {
+			evicted = s.evict(now, window)
+		}
+		s.sites[key] = &dedupeSite{windowStart: now}
+		return 0, true
+	}
+
+	if now.Sub(site.windowStart) < window {
+		site.suppressed++
+		site.last = ent
+		site.core = core
+		if site.timer == nil {
+			gen := site.gen
+			site.timer = time.AfterFunc(site.windowStart.Add(window).Sub(now), func() {
+				s.flush(key, site, gen)
+			})
+		}
+		return 0, false
+	}
+
+	suppressed := site.suppressed
+	site.stop()
+	site.windowStart = now
+	return suppressed, true
+}
+
+// flush 窗口结束时补发汇总日志，调用点已重新输出或被淘汰时不处理
+func (s *dedupeState) flush(key string, site *dedupeSite, gen uint64) {
+	s.mu.Lock()
+	if s.sites[key] != site || site.gen != gen {
+		s.mu.Unlock()
+		return
+	}
+	summary := site.summary()
+	site.stop()
+	s.mu.Unlock()
+
+	if summary.suppressed > 0 {
+		summary.write()
+	}
+}
+
+// evict 清理已过期的调用点，仍然超过上限时淘汰窗口最早的调用点，返回需要补发的汇总
+// 调用方需持有锁
+func (s *dedupeState) evict(now time.Time, window time.Duration) []dedupeSummary {
+	var summaries []dedupeSummary
+	remove := func(key string, site *dedupeSite) {
+		if site.suppressed > 0 {
+			summaries = append(summaries, site.summary())
+		}
+		site.stop()
+		delete(s.sites, key)
+	}
+
+	for key, site := range s.sites {
+		if now.Sub(site.windowStart) >= window {
+			remove(key, site)
+		}
+	}
+
+	for len(s.sites) >= dedupeMaxSites {
+		var oldestKey string
+		var oldest *dedupeSite
+		for key, site := range s.sites {
+			if oldest == nil || site.windowStart.Before(oldest.windowStart) {
+				oldestKey, oldest = key, site
+			}
+		}
+		remove(oldestKey, oldest)
+	}
+	return summaries
+}
+
+func (site *dedupeSite) summary() dedupeSummary {
+	return dedupeSummary{ent: site.last, core: site.core, suppressed: site.suppressed}
+}
+
+// stop 停止定时器并清空抑制计数，调用方需持有锁
+func (site *dedupeSite) stop() {
+	if site.timer != nil {
+		site.timer.Stop()
+		site.timer = nil
+	}
+	site.suppressed = 0
+	site.last = zapcore.Entry{}
+	site.core = nil
+	site.gen++
+}
+
+type dedupeCore struct {
+	zapcore.Core
+	config DedupeConfig
+	state  *dedupeState
+}
+
+func newDedupeCore(core zapcore.Core, config DedupeConfig) zapcore.Core {
+	if config.Window <= 0 {
+		config.Window = time.Minute
+	}
+	if config.Level == nil {
+		config.Level = zapcore.ErrorLevel
+	}
+
+	return &dedupeCore{
+		Core:   core,
+		config: config,
+		state:  &dedupeState{sites: make(map[string]*dedupeSite)},
+	}
+}
+
+func (c *dedupeCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupeCore{
+		Core:   c.Core.With(fields),
+		config: c.config,
+		state:  c.state,
+	}
+}
+
+func (c *dedupeCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+
+	if !c.config.Level.Enabled(ent.Level) {
+		return c.Core.Check(ent, ce)
+	}
+
+	suppressed, ok := c.state.allow(dedupeKey(ent), ent, c.Core, c.config.Window)
+	if !ok {
+		recordDropped(ent.Level, dropReasonDedupe)
+		return ce
+	}
+
+	if suppressed > 0 {
+		return c.Core.With([]zapcore.Field{zap.Int64("suppressed_count", suppressed)}).Check(ent, ce)
+	}
+
+	return c.Core.Check(ent, ce)
+}
+
+// dedupeKey 使用调用位置，找不到时使用 logger 名 + 消息
+func dedupeKey(ent zapcore.Entry) string {
+	if ent.Caller.Defined {
+		return ent.Level.String() + "|" + ent.Caller.String()
+	}
+	if site, ok := callSite(); ok {
+		return ent.Level.String() + "|" + site
+	}
+	return ent.Level.String() + "|" + ent.LoggerName + "|" + ent.Message
+}
+
+// loggerMethodPrefix 本包方法（Logger 的包装函数与各 core）的函数名前缀
+var loggerMethodPrefix = reflect.TypeOf(Logger{}).PkgPath() + ".(*"
+
+// callSite zap 在 core.Check 之后才填充 Entry.Caller，这里沿调用栈跳过 zap 与本包的方法找到调用方
+func callSite() (string, bool) {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "go.uber.org/zap") && !strings.HasPrefix(frame.Function, loggerMethodPrefix) {
+			return frame.File + ":" + strconv.Itoa(frame.Line), true
+		}
+		if !more {
+			return "", false
+		}
+	}
+}
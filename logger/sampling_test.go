@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func writeEntry(core zapcore.Core, ent zapcore.Entry) {
+	if ce := core.Check(ent, nil); ce != nil {
+		ce.Write()
+	}
+}
+
+func suppressedCount(e observer.LoggedEntry) (int64, bool) {
+	v, ok := e.ContextMap()["suppressed_count"]
+	if !ok {
+		return 0, false
+	}
+	return v.(int64), true
+}
+
+func TestSamplerCore(t *testing.T) {
+	obs, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(newSamplerCore(obs, SamplingConfig{Tick: time.Minute, First: 2}))
+
+	for i := 0; i < 5; i++ {
+		logger.Info("hello")
+	}
+	logger.Info("other")
+
+	if logs.FilterMessage("hello").Len() != 2 || logs.FilterMessage("other").Len() != 1 {
+		t.Fatalf("unexpected logs: %+v", logs.All())
+	}
+}
+
+func TestDedupeCarriesSuppressedCount(t *testing.T) {
+	obs, logs := observer.New(zapcore.DebugLevel)
+	core := newDedupeCore(obs, DedupeConfig{Window: time.Minute, Level: zapcore.ErrorLevel})
+
+	now := time.Now()
+	writeEntry(core, zapcore.Entry{Level: zapcore.ErrorLevel, Message: "db down", Time: now})
+	writeEntry(core, zapcore.Entry{Level: zapcore.ErrorLevel, Message: "db down", Time: now.Add(time.Second)})
+	writeEntry(core, zapcore.Entry{Level: zapcore.ErrorLevel, Message: "db down", Time: now.Add(2 * time.Second)})
+	writeEntry(core, zapcore.Entry{Level: zapcore.InfoLevel, Message: "db down", Time: now.Add(2 * time.Second)})
+	writeEntry(core, zapcore.Entry{Level: zapcore.ErrorLevel, Message: "db down", Time: now.Add(2 * time.Minute)})
+
+	entries := logs.All()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", entries)
+	}
+	if _, ok := suppressedCount(entries[0]); ok {
+		t.Fatalf("first entry should not carry suppressed_count: %+v", entries[0])
+	}
+	if entries[1].Level != zapcore.InfoLevel {
+		t.Fatalf("entries below Level should not be deduped: %+v", entries[1])
+	}
+	if n, _ := suppressedCount(entries[2]); n != 2 {
+		t.Fatalf("suppressed_count = %d, want 2", n)
+	}
+}
+
+func TestDedupeFlushesSilentSite(t *testing.T) {
+	obs, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(newDedupeCore(obs, DedupeConfig{Window: 50 * time.Millisecond}))
+
+	for i := 0; i < 4; i++ {
+		logger.Error("retrying")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for logs.Len() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	entries := logs.All()
+	if len(entries) != 2 || entries[1].Message != "retrying" {
+		t.Fatalf("expected a summary entry, got %+v", entries)
+	}
+	if n, _ := suppressedCount(entries[1]); n != 3 {
+		t.Fatalf("suppressed_count = %d, want 3", n)
+	}
+
+	// 汇总已补发，之后的日志不再重复携带
+	time.Sleep(60 * time.Millisecond)
+	logger.Error("retrying")
+	if entries := logs.All(); len(entries) != 3 {
+		t.Fatalf("unexpected logs: %+v", entries)
+	} else if _, ok := suppressedCount(entries[2]); ok {
+		t.Fatalf("suppressed_count should not be reported twice: %+v", entries[2])
+	}
+}
+
+func TestDedupeBounded(t *testing.T) {
+	obs, logs := observer.New(zapcore.DebugLevel)
+	core := newDedupeCore(obs, DedupeConfig{Window: time.Hour}).(*dedupeCore)
+
+	now := time.Now()
+	first := zapcore.NewEntryCaller(0, "first.go", 1, true)
+	writeEntry(core, zapcore.Entry{Level: zapcore.ErrorLevel, Message: "first", Time: now, Caller: first})
+	writeEntry(core, zapcore.Entry{Level: zapcore.ErrorLevel, Message: "first", Time: now, Caller: first})
+	for i := 0; i < dedupeMaxSites+10; i++ {
+		caller := zapcore.NewEntryCaller(0, "site.go", i, true)
+		writeEntry(core, zapcore.Entry{Level: zapcore.ErrorLevel, Message: fmt.Sprintf("site-%d", i), Time: now.Add(time.Second), Caller: caller})
+	}
+
+	core.state.mu.Lock()
+	size := len(core.state.sites)
+	core.state.mu.Unlock()
+	if size > dedupeMaxSites {
+		t.Fatalf("dedupe sites = %d, want <= %d", size, dedupeMaxSites)
+	}
+
+	// 被淘汰的调用点立即补发汇总
+	flushed := logs.FilterMessage("first").All()
+	if len(flushed) != 2 {
+		t.Fatalf("expected evicted site to flush its summary, got %+v", flushed)
+	}
+	if n, _ := suppressedCount(flushed[1]); n != 1 {
+		t.Fatalf("suppressed_count = %d, want 1", n)
+	}
+}
+
+func TestLoggerDedupeByCallSite(t *testing.T) {
+	obs, logs := observer.New(zapcore.DebugLevel)
+	log, err := NewLogger(WithDedupe(DedupeConfig{Window: time.Hour}), WithCores(obs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		log.Errorf(ctx, "order %d failed", i)
+		log.DefaultLogger.Sugar().Errorf("order %d failed", i)
+	}
+	log.Infof(ctx, "order %d created", 1)
+	log.Infof(ctx, "order %d created", 2)
+
+	entries := logs.All()
+	if len(entries) != 4 {
+		t.Fatalf("expected one entry per error call site and all info entries, got %d: %+v", len(entries), entries)
+	}
+	if entries[0].Message != "order 0 failed" || entries[1].Message != "order 0 failed" {
+		t.Fatalf("unexpected entries: %+v", entries[:2])
+	}
+}
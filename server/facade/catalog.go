@@ -0,0 +1,98 @@
+package facade
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ExportJSON 以 JSON 数组导出错误码目录
+func ExportJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(Catalog())
+}
+
+// ExportMarkdown 以 Markdown 表格导出错误码目录，按模块分节
+func ExportMarkdown(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("# Error Codes\n")
+
+	module := ""
+	for _, e := range Catalog() {
+		if e.Module != module {
+			module = e.Module
+			sb.WriteString(fmt.Sprintf("\n## %s\n\n", module))
+			sb.WriteString("| Code | HTTP Status | Message |\n")
+			sb.WriteString("| ---- | ----------- | ------- |\n")
+		}
+		sb.WriteString(fmt.Sprintf("| %d | %d %s | %s |\n", e.Code, e.HttpStatus, http.StatusText(e.HttpStatus), escapeMarkdown(e.Message)))
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// OpenAPIComponents 生成 OpenAPI 3 components 片段
+// schemas 中包含 FacadeError 与 ErrorResponse，responses 中每个错误码对应一个 Error<code> 响应
+func OpenAPIComponents() map[string]any {
+	responses := make(map[string]any)
+	codes := make([]int, 0)
+
+	for _, e := range Catalog() {
+		codes = append(codes, e.Code)
+		responses["Error"+strconv.Itoa(e.Code)] = map[string]any{
+			"description": fmt.Sprintf("%s (%s)", e.Message, e.Module),
+			"content": map[string]any{
+				"application/json": map[string]any{
+					"schema": map[string]any{"$ref": "#/components/schemas/ErrorResponse"},
+					"example": map[string]any{
+						"status": StatusError,
+						"error": map[string]any{
+							"code": e.Code,
+							"msg":  e.Message,
+						},
+						"data": nil,
+					},
+				},
+			},
+		}
+	}
+
+	return map[string]any{
+		"schemas": map[string]any{
+			"FacadeError": map[string]any{
+				"type":     "object",
+				"required": []string{"code", "msg"},
+				"properties": map[string]any{
+					"code": map[string]any{"type": "integer", "enum": codes},
+					"msg":  map[string]any{"type": "string"},
+				},
+			},
+			"ErrorResponse": map[string]any{
+				"type":     "object",
+				"required": []string{"status", "error"},
+				"properties": map[string]any{
+					"status": map[string]any{"type": "string", "enum": []string{StatusError}},
+					"error":  map[string]any{"$ref": "#/components/schemas/FacadeError"},
+					"data":   map[string]any{"nullable": true},
+				},
+			},
+		},
+		"responses": responses,
+	}
+}
+
+// ExportOpenAPI 以 JSON 导出 OpenAPI components 片段，可合并进 swagger 文档
+func ExportOpenAPI(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{"components": OpenAPIComponents()})
+}
+
+func escapeMarkdown(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
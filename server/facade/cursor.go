@@ -0,0 +1,64 @@
+package facade
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
+)
+
+// ErrInvalidCursor 游标格式错误或签名校验失败
+var ErrInvalidCursor = xerror.NewWithKind(xerror.KindInvalidArgument, "invalid cursor")
+
+// CursorCodec 生成不透明且带签名的分页游标，防止客户端篡改游标内容
+// 游标格式为 base64url(json) + "." + base64url(hmac-sha256)
+type CursorCodec struct {
+	secret []byte
+}
+
+func NewCursorCodec(secret []byte) *CursorCodec {
+	return &CursorCodec{secret: secret}
+}
+
+// Encode 将游标内容（如最后一条记录的排序键）编码为字符串
+func (c *CursorCodec) Encode(v any) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", xerror.Wrap(err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(c.sign(encoded)), nil
+}
+
+// Decode 校验签名并解码游标到 v，失败时返回 ErrInvalidCursor
+func (c *CursorCodec) Decode(cursor string, v any) error {
+	encoded, sig, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return xerror.Wrap(ErrInvalidCursor)
+	}
+
+	expected, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(expected, c.sign(encoded)) {
+		return xerror.Wrap(ErrInvalidCursor)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return xerror.Wrap(ErrInvalidCursor)
+	}
+
+	if err := json.Unmarshal(payload, v); err != nil {
+		return xerror.WrapWithMessage(ErrInvalidCursor, err.Error())
+	}
+	return nil
+}
+
+func (c *CursorCodec) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
@@ -5,39 +5,19 @@ import (
 	"runtime"
 
 	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
+	"golang.org/x/text/language"
 )
 
 var (
-	ErrServerInternal = &Error{
-		Code:          20001,
-		HttpStatus:    500,
-		FacadeMessage: "Server Internal Error",
-	}
-	ErrBadRequest = &Error{
-		Code:          20002,
-		HttpStatus:    400,
-		FacadeMessage: "Bad Request",
-	}
-	ErrUnauthorized = &Error{
-		Code:          20003,
-		HttpStatus:    401,
-		FacadeMessage: "Unauthorized",
-	}
-	ErrorNotFound = &Error{
-		Code:          20004,
-		HttpStatus:    404,
-		FacadeMessage: "Not Found",
-	}
-	ErrForbidden = &Error{
-		Code:          20005,
-		HttpStatus:    403,
-		FacadeMessage: "Forbidden",
-	}
-	ErrTooManyRequests = &Error{
-		Code:          20006,
-		HttpStatus:    429,
-		FacadeMessage: "Too Many Requests",
-	}
+	ErrServerInternal  = CommonModule.Define(20001, 500, "Server Internal Error")
+	ErrBadRequest      = CommonModule.Define(20002, 400, "Bad Request")
+	ErrUnauthorized    = CommonModule.Define(20003, 401, "Unauthorized")
+	ErrorNotFound      = CommonModule.Define(20004, 404, "Not Found")
+	ErrForbidden       = CommonModule.Define(20005, 403, "Forbidden")
+	ErrTooManyRequests = CommonModule.Define(20006, 429, "Too Many Requests")
+	ErrConflict        = CommonModule.Define(20007, 409, "Conflict")
+	ErrGatewayTimeout  = CommonModule.Define(20008, 504, "Gateway Timeout")
+	ErrUnavailable     = CommonModule.Define(20009, 503, "Service Unavailable")
 )
 
 type Error struct {
@@ -45,19 +25,34 @@ type Error struct {
 	Code          int    `json:"code"`
 	FacadeMessage string `json:"msg"`
 	InternalError error  `json:"-" swaggerignore:"true"`
+	// Details 字段级别的错误，如参数校验失败
+	Details []FieldError `json:"details,omitempty"`
+	// Params 文案模板中 {name} 占位符的参数
+	Params map[string]any `json:"-" swaggerignore:"true"`
+
+	// localizable 通过 Module.Define 声明的模板及其派生错误可按错误码本地化
+	localizable bool
+	// detail Facade 追加的说明，本地化时拼接在翻译后的文案之后
+	detail string
 }
 
 func (e *Error) StatusCode() int {
 	return e.HttpStatus
 }
 
-func (e *Error) Wrap(err error) *Error {
-	res := &Error{
-		HttpStatus:    e.HttpStatus,
-		Code:          e.Code,
-		FacadeMessage: e.FacadeMessage,
-		InternalError: e.InternalError,
+func (e *Error) Error() string {
+	if e.InternalError == nil {
+		return fmt.Sprintf("%d %s", e.Code, e.FacadeMessage)
 	}
+	return fmt.Sprintf("%d %s: %v", e.Code, e.FacadeMessage, e.InternalError)
+}
+
+func (e *Error) Unwrap() error {
+	return e.InternalError
+}
+
+func (e *Error) Wrap(err error) *Error {
+	res := e.clone()
 
 	if res.InternalError == nil {
 		res.InternalError = fmt.Errorf("%w", xerror.Wrap(err))
@@ -68,15 +63,15 @@ func (e *Error) Wrap(err error) *Error {
 }
 
 func (e *Error) Facade(errorMessage string, params ...any) *Error {
-	res := &Error{
-		HttpStatus:    e.HttpStatus,
-		Code:          e.Code,
-		FacadeMessage: e.FacadeMessage,
-		InternalError: e.InternalError,
-	}
+	res := e.clone()
 
 	errorMessage = fmt.Sprintf(errorMessage, params...)
 	res.FacadeMessage = fmt.Sprintf("%s: %s", e.FacadeMessage, errorMessage)
+	if res.detail == "" {
+		res.detail = errorMessage
+	} else {
+		res.detail = fmt.Sprintf("%s: %s", res.detail, errorMessage)
+	}
 
 	caller := e.getCaller(2)
 	if res.InternalError == nil {
@@ -87,6 +82,68 @@ func (e *Error) Facade(errorMessage string, params ...any) *Error {
 	return res
 }
 
+// WithParams 设置文案模板参数，kv 依次为 key, value
+// 例如 Define(31001, 409, "Order {id} closed").WithParams("id", orderID)
+func (e *Error) WithParams(kv ...any) *Error {
+	res := e.clone()
+	res.Params = make(map[string]any, len(e.Params)+len(kv)/2)
+	for k, v := range e.Params {
+		res.Params[k] = v
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		res.Params[fmt.Sprint(kv[i])] = kv[i+1]
+	}
+
+	if e.localizable {
+		if tmpl, ok := lookupMessage(e.Code); ok {
+			res.FacadeMessage = renderMessage(tmpl, res.Params)
+			if res.detail != "" {
+				res.FacadeMessage = fmt.Sprintf("%s: %s", res.FacadeMessage, res.detail)
+			}
+		}
+	}
+	return res
+}
+
+// WithDetails 附加字段级别的错误
+func (e *Error) WithDetails(details ...FieldError) *Error {
+	res := e.clone()
+	res.Details = append(append([]FieldError(nil), e.Details...), details...)
+	return res
+}
+
+// Localize 返回按 lang 翻译文案后的错误，没有对应文案或不是声明的模板时原样返回
+func (e *Error) Localize(lang language.Tag) *Error {
+	if !e.localizable {
+		return e
+	}
+
+	msg, ok := Translate(e.Code, lang, e.Params)
+	if !ok {
+		return e
+	}
+
+	res := e.clone()
+	res.FacadeMessage = msg
+	if res.detail != "" {
+		res.FacadeMessage = fmt.Sprintf("%s: %s", msg, res.detail)
+	}
+	return res
+}
+
+func (e *Error) clone() *Error {
+	return &Error{
+		HttpStatus:    e.HttpStatus,
+		Code:          e.Code,
+		FacadeMessage: e.FacadeMessage,
+		InternalError: e.InternalError,
+		Details:       e.Details,
+		Params:        e.Params,
+		localizable:   e.localizable,
+		detail:        e.detail,
+	}
+}
+
 func (e *Error) getCaller(skip int) string {
 	_, file, line, _ := runtime.Caller(skip)
 	return fmt.Sprintf("%s %d", file, line)
@@ -95,8 +152,8 @@ func (e *Error) getCaller(skip int) string {
 // Convenience functions for creating common errors
 func NewBadRequestError(message string, details ...string) *Error {
 	err := &Error{
-		HttpStatus:    400,
-		Code:          20002,
+		HttpStatus:    ErrBadRequest.HttpStatus,
+		Code:          ErrBadRequest.Code,
 		FacadeMessage: message,
 	}
 	if len(details) > 0 {
@@ -107,8 +164,8 @@ func NewBadRequestError(message string, details ...string) *Error {
 
 func NewInternalServerError(message string, details ...string) *Error {
 	err := &Error{
-		HttpStatus:    500,
-		Code:          20001,
+		HttpStatus:    ErrServerInternal.HttpStatus,
+		Code:          ErrServerInternal.Code,
 		FacadeMessage: message,
 	}
 	if len(details) > 0 {
@@ -121,8 +178,8 @@ func NewInternalServerError(message string, details ...string) *Error {
 // Optional details parameter appends additional context to the message
 func NewNotFoundError(message string, details ...string) *Error {
 	err := &Error{
-		HttpStatus:    404,
-		Code:          20004,
+		HttpStatus:    ErrorNotFound.HttpStatus,
+		Code:          ErrorNotFound.Code,
 		FacadeMessage: message,
 	}
 	if len(details) > 0 {
@@ -133,8 +190,8 @@ func NewNotFoundError(message string, details ...string) *Error {
 
 func NewForbiddenError(message string, details ...string) *Error {
 	err := &Error{
-		HttpStatus:    403,
-		Code:          20005,
+		HttpStatus:    ErrForbidden.HttpStatus,
+		Code:          ErrForbidden.Code,
 		FacadeMessage: message,
 	}
 	if len(details) > 0 {
@@ -145,8 +202,8 @@ func NewForbiddenError(message string, details ...string) *Error {
 
 func NewUnauthorizedError(message string, details ...string) *Error {
 	err := &Error{
-		HttpStatus:    401,
-		Code:          20003,
+		HttpStatus:    ErrUnauthorized.HttpStatus,
+		Code:          ErrUnauthorized.Code,
 		FacadeMessage: message,
 	}
 	if len(details) > 0 {
@@ -0,0 +1,157 @@
+package facade
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed locales/*.yaml
+var builtinLocales embed.FS
+
+var (
+	i18nMu sync.RWMutex
+	// defaultLanguage 无法匹配请求语言时使用的语言
+	defaultLanguage = language.English
+	// messages 语言 -> 错误码 -> 文案模板
+	messages = make(map[language.Tag]map[int]string)
+	matcher  language.Matcher
+	tags     []language.Tag
+)
+
+func init() {
+	if err := LoadMessagesFS(builtinLocales, "locales"); err != nil {
+		panic(err)
+	}
+}
+
+// DefaultLanguage 返回无法匹配请求语言时使用的语言，默认为英文
+func DefaultLanguage() language.Tag {
+	i18nMu.RLock()
+	defer i18nMu.RUnlock()
+	return defaultLanguage
+}
+
+// SetDefaultLanguage 设置无法匹配请求语言时使用的语言
+func SetDefaultLanguage(lang language.Tag) {
+	i18nMu.Lock()
+	defer i18nMu.Unlock()
+
+	defaultLanguage = lang
+	rebuildMatcher()
+}
+
+// RegisterMessages 注册某个语言的错误码文案，与已有文案合并，相同错误码覆盖
+func RegisterMessages(lang language.Tag, msgs map[int]string) {
+	i18nMu.Lock()
+	defer i18nMu.Unlock()
+
+	catalog, ok := messages[lang]
+	if !ok {
+		catalog = make(map[int]string, len(msgs))
+		messages[lang] = catalog
+	}
+	for code, msg := range msgs {
+		catalog[code] = msg
+	}
+
+	rebuildMatcher()
+}
+
+// LoadMessagesFS 加载 dir 下所有 <lang>.yaml 文案文件，如 zh.yaml、en-US.yaml
+func LoadMessagesFS(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.yaml"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		lang, err := language.Parse(strings.TrimSuffix(path.Base(file), ".yaml"))
+		if err != nil {
+			return fmt.Errorf("facade: invalid locale file %s: %w", file, err)
+		}
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+
+		msgs := make(map[int]string)
+		if err := yaml.Unmarshal(data, &msgs); err != nil {
+			return fmt.Errorf("facade: parse locale file %s: %w", file, err)
+		}
+
+		RegisterMessages(lang, msgs)
+	}
+
+	return nil
+}
+
+// MatchLanguage 按优先级依次解析 Accept-Language 格式的头部值，返回第一个能匹配到已注册文案的语言
+func MatchLanguage(headerValues ...string) language.Tag {
+	i18nMu.RLock()
+	defer i18nMu.RUnlock()
+
+	for _, v := range headerValues {
+		if v == "" {
+			continue
+		}
+		desired, _, err := language.ParseAcceptLanguage(v)
+		if err != nil || len(desired) == 0 {
+			continue
+		}
+		if _, idx, conf := matcher.Match(desired...); conf != language.No {
+			return tags[idx]
+		}
+	}
+
+	return defaultLanguage
+}
+
+// Translate 返回错误码在指定语言下渲染后的文案
+func Translate(code int, lang language.Tag, params map[string]any) (string, bool) {
+	i18nMu.RLock()
+	tmpl, ok := messages[lang][code]
+	i18nMu.RUnlock()
+
+	if !ok {
+		return "", false
+	}
+	return renderMessage(tmpl, params), true
+}
+
+// rebuildMatcher defaultLanguage 排在首位，其余语言按名称排序以保证匹配结果稳定，调用方需持有写锁
+func rebuildMatcher() {
+	others := make([]language.Tag, 0, len(messages))
+	for tag := range messages {
+		if tag != defaultLanguage {
+			others = append(others, tag)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool {
+		return others[i].String() < others[j].String()
+	})
+
+	tags = append([]language.Tag{defaultLanguage}, others...)
+	matcher = language.NewMatcher(tags)
+}
+
+// renderMessage 将模板中的 {name} 替换为参数值
+func renderMessage(tmpl string, params map[string]any) string {
+	if len(params) == 0 {
+		return tmpl
+	}
+
+	oldnew := make([]string, 0, len(params)*2)
+	for k, v := range params {
+		oldnew = append(oldnew, "{"+k+"}", fmt.Sprint(v))
+	}
+	return strings.NewReplacer(oldnew...).Replace(tmpl)
+}
@@ -0,0 +1,56 @@
+package facade
+
+import (
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestLocalize(t *testing.T) {
+	lang := MatchLanguage("zh-CN,zh;q=0.9,en;q=0.8")
+	if lang != language.Chinese {
+		t.Fatalf("unexpected language: %v", lang)
+	}
+	if MatchLanguage("", "fr-FR") != DefaultLanguage() {
+		t.Fatal("unsupported language should fall back to default")
+	}
+	if MatchLanguage("", "zh") != language.Chinese {
+		t.Fatal("should fall back to propagated Content-Language")
+	}
+
+	if got := ErrTooManyRequests.Localize(lang).FacadeMessage; got != "操作太频繁，休息一下" {
+		t.Fatalf("unexpected message: %s", got)
+	}
+	if got := ErrBadRequest.Facade("missing %s", "name").Localize(lang).FacadeMessage; got != "请求参数错误: missing name" {
+		t.Fatalf("unexpected message: %s", got)
+	}
+	if got := NewBadRequestError("custom").Localize(lang).FacadeMessage; got != "custom" {
+		t.Fatalf("ad-hoc errors should not be localized: %s", got)
+	}
+
+	isolateRegistry(t)
+	quota := NewModule("quota", 32000, 32999)
+	errQuota := quota.Define(32001, 429, "Quota {name} exceeded, limit {limit}")
+	RegisterMessages(language.Chinese, map[int]string{32001: "{name} 配额已用完，上限 {limit}"})
+
+	e := errQuota.WithParams("name", "tts", "limit", 100)
+	if e.FacadeMessage != "Quota tts exceeded, limit 100" {
+		t.Fatalf("unexpected default message: %s", e.FacadeMessage)
+	}
+	if got := e.Localize(language.Chinese).FacadeMessage; got != "tts 配额已用完，上限 100" {
+		t.Fatalf("unexpected localized message: %s", got)
+	}
+}
+
+func TestDefaultLanguageChange(t *testing.T) {
+	saved := DefaultLanguage()
+	t.Cleanup(func() { SetDefaultLanguage(saved) })
+
+	SetDefaultLanguage(language.Chinese)
+	if got := MatchLanguage("fr-FR"); got != language.Chinese {
+		t.Fatalf("unexpected language: %v", got)
+	}
+	if got := MatchLanguage("en-US"); got != language.English {
+		t.Fatalf("unexpected language: %v", got)
+	}
+}
@@ -0,0 +1,83 @@
+package facade
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
+)
+
+type sentinelMapping struct {
+	target   error
+	template *Error
+}
+
+var (
+	mappingMu sync.RWMutex
+
+	// kindMappings xerror 类别到 facade 错误模板的映射
+	kindMappings = map[xerror.Kind]*Error{
+		xerror.KindInvalidArgument: ErrBadRequest,
+		xerror.KindNotFound:        ErrorNotFound,
+		xerror.KindUnauthenticated: ErrUnauthorized,
+		xerror.KindPermission:      ErrForbidden,
+		xerror.KindRateLimited:     ErrTooManyRequests,
+		xerror.KindAlreadyExists:   ErrConflict,
+		xerror.KindTimeout:         ErrGatewayTimeout,
+		xerror.KindUnavailable:     ErrUnavailable,
+	}
+
+	// sentinelMappings 哨兵错误到 facade 错误模板的映射，按注册顺序匹配
+	sentinelMappings []sentinelMapping
+)
+
+// RegisterKind 注册 xerror 类别对应的 facade 错误模板，重复注册会覆盖
+func RegisterKind(kind xerror.Kind, template *Error) {
+	mappingMu.Lock()
+	defer mappingMu.Unlock()
+	kindMappings[kind] = template
+}
+
+// RegisterSentinel 注册哨兵错误对应的 facade 错误模板，使用 errors.Is 匹配，优先于类别映射
+func RegisterSentinel(target error, template *Error) {
+	mappingMu.Lock()
+	defer mappingMu.Unlock()
+	sentinelMappings = append(sentinelMappings, sentinelMapping{target: target, template: template})
+}
+
+// FromError 将任意错误转换为 facade 错误
+// 错误链上已有 *Error 时直接返回；否则依次按哨兵错误、xerror 类别匹配模板，
+// 均未匹配时使用 ErrServerInternal，原始错误保存在 InternalError 中
+func FromError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var facadeErr *Error
+	if errors.As(err, &facadeErr) {
+		return facadeErr
+	}
+
+	mappingMu.RLock()
+	template := lookupTemplate(err)
+	mappingMu.RUnlock()
+
+	res := template.clone()
+	res.InternalError = err
+	return res
+}
+
+// lookupTemplate 调用方需持有读锁
+func lookupTemplate(err error) *Error {
+	for _, m := range sentinelMappings {
+		if errors.Is(err, m.target) {
+			return m.template
+		}
+	}
+
+	if template, ok := kindMappings[xerror.KindOf(err)]; ok {
+		return template
+	}
+
+	return ErrServerInternal
+}
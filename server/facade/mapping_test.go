@@ -0,0 +1,57 @@
+package facade
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
+)
+
+func TestFromError(t *testing.T) {
+	mappingMu.RLock()
+	saved := sentinelMappings
+	mappingMu.RUnlock()
+	t.Cleanup(func() {
+		mappingMu.Lock()
+		sentinelMappings = saved
+		mappingMu.Unlock()
+	})
+
+	errQuotaExceeded := errors.New("quota exceeded")
+	RegisterSentinel(errQuotaExceeded, ErrTooManyRequests)
+
+	notFound := xerror.NewWithKind(xerror.KindNotFound, "user not found")
+
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		code   int
+	}{
+		{"kind", xerror.WrapWithMessage(notFound, "get user"), 404, ErrorNotFound.Code},
+		{"sentinel", fmt.Errorf("charge: %w", errQuotaExceeded), 429, ErrTooManyRequests.Code},
+		{"facade", fmt.Errorf("wrapped: %w", ErrForbidden.Facade("no access")), 403, ErrForbidden.Code},
+		{"already exists", xerror.NewWithKind(xerror.KindAlreadyExists, "user exists"), 409, ErrConflict.Code},
+		{"timeout", xerror.NewWithKind(xerror.KindTimeout, "upstream timeout"), 504, ErrGatewayTimeout.Code},
+		{"unavailable", xerror.NewWithKind(xerror.KindUnavailable, "upstream down"), 503, ErrUnavailable.Code},
+		{"default", io.EOF, 500, ErrServerInternal.Code},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := FromError(c.err)
+			if res.StatusCode() != c.status || res.Code != c.code {
+				t.Fatalf("got %d/%d, want %d/%d", res.StatusCode(), res.Code, c.status, c.code)
+			}
+			if !errors.Is(res, c.err) && !errors.As(c.err, new(*Error)) {
+				t.Fatalf("original error should be kept in InternalError")
+			}
+		})
+	}
+
+	if FromError(nil) != nil {
+		t.Fatal("nil error should map to nil")
+	}
+}
@@ -11,3 +11,15 @@ type PageResponse[T any] struct {
 	// 总条数
 	Total int `json:"total"`
 }
+
+// @Description 游标分页响应
+type CursorPageResponse[T any] struct {
+	// list
+	List []T `json:"list" swaggertype:"object"`
+	// 下一页游标，没有更多数据时为空
+	NextCursor string `json:"next_cursor"`
+	// 是否还有更多数据
+	HasMore bool `json:"has_more"`
+	// 页大小
+	PageSize int `json:"page_size"`
+}
@@ -0,0 +1,52 @@
+package facade
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ContentTypeProblemJSON RFC 9457 problem details 的媒体类型
+const ContentTypeProblemJSON = "application/problem+json"
+
+// ProblemTypeBaseURI problem details 中 type 字段的前缀，为空时 type 为 about:blank
+// 例如设置为 "https://errors.example.com"，错误码 20002 的 type 为 https://errors.example.com/20002
+var ProblemTypeBaseURI = ""
+
+// FieldError 字段级别的错误，如参数校验失败
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+// ProblemDetails RFC 9457 problem details，code、trace_id、errors 为扩展字段
+type ProblemDetails struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     int          `json:"code"`
+	TraceID  string       `json:"trace_id,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// NewProblemDetails 将 facade 错误转换为 problem details，instance 一般为请求路径
+func NewProblemDetails(err *Error, instance, traceID string) *ProblemDetails {
+	problemType := "about:blank"
+	if ProblemTypeBaseURI != "" {
+		problemType = strings.TrimSuffix(ProblemTypeBaseURI, "/") + "/" + strconv.Itoa(err.Code)
+	}
+
+	return &ProblemDetails{
+		Type:     problemType,
+		Title:    http.StatusText(err.HttpStatus),
+		Status:   err.HttpStatus,
+		Detail:   err.FacadeMessage,
+		Instance: instance,
+		Code:     err.Code,
+		TraceID:  traceID,
+		Errors:   err.Details,
+	}
+}
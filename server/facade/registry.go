@@ -0,0 +1,127 @@
+package facade
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Module 错误码模块，每个模块分配一段不重叠的错误码区间，模块内的错误码必须落在区间内
+type Module struct {
+	name string
+	min  int
+	max  int
+}
+
+// CatalogEntry 错误码目录中的一项
+type CatalogEntry struct {
+	Module     string `json:"module"`
+	Code       int    `json:"code"`
+	HttpStatus int    `json:"http_status"`
+	Message    string `json:"msg"`
+}
+
+var (
+	registryMu sync.RWMutex
+	modules    = make(map[string]*Module)
+	catalog    = make(map[int]CatalogEntry)
+
+	// CommonModule 通用错误码区间，kiwi-lib 内置错误使用
+	CommonModule = NewModule("common", 20000, 20999)
+)
+
+// NewModule 分配错误码区间 [min, max]，模块名重复或区间重叠时 panic，应在包初始化时调用
+func NewModule(name string, min, max int) *Module {
+	if min > max {
+		panic(fmt.Sprintf("facade: invalid code range [%d, %d] for module %s", min, max, name))
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := modules[name]; ok {
+		panic(fmt.Sprintf("facade: module %s already exists", name))
+	}
+	for _, m := range modules {
+		if min <= m.max && m.min <= max {
+			panic(fmt.Sprintf("facade: code range [%d, %d] of module %s overlaps with module %s [%d, %d]", min, max, name, m.name, m.min, m.max))
+		}
+	}
+
+	m := &Module{name: name, min: min, max: max}
+	modules[name] = m
+	return m
+}
+
+func (m *Module) Name() string {
+	return m.name
+}
+
+// Define 在模块内声明一个错误模板，错误码越界或重复时 panic，应在包初始化时调用
+func (m *Module) Define(code int, httpStatus int, message string) *Error {
+	if code < m.min || code > m.max {
+		panic(fmt.Sprintf("facade: code %d is out of range [%d, %d] of module %s", code, m.min, m.max, m.name))
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if exist, ok := catalog[code]; ok {
+		panic(fmt.Sprintf("facade: code %d of module %s is already defined by module %s (%s)", code, m.name, exist.Module, exist.Message))
+	}
+
+	catalog[code] = CatalogEntry{
+		Module:     m.name,
+		Code:       code,
+		HttpStatus: httpStatus,
+		Message:    message,
+	}
+
+	return &Error{
+		HttpStatus:    httpStatus,
+		Code:          code,
+		FacadeMessage: message,
+		localizable:   true,
+	}
+}
+
+// Catalog 返回所有已声明的错误模板，按错误码排序
+func Catalog() []CatalogEntry {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	entries := make([]CatalogEntry, 0, len(catalog))
+	for _, e := range catalog {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Code < entries[j].Code
+	})
+	return entries
+}
+
+// Lookup 根据错误码查找已声明的错误模板
+func Lookup(code int) (*Error, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	e, ok := catalog[code]
+	if !ok {
+		return nil, false
+	}
+	return &Error{
+		HttpStatus:    e.HttpStatus,
+		Code:          e.Code,
+		FacadeMessage: e.Message,
+		localizable:   true,
+	}, true
+}
+
+// lookupMessage 返回错误码在目录中声明的默认文案模板
+func lookupMessage(code int) (string, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	e, ok := catalog[code]
+	return e.Message, ok
+}
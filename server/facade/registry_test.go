@@ -0,0 +1,63 @@
+package facade
+
+import (
+	"bytes"
+	"maps"
+	"strings"
+	"testing"
+)
+
+func expectPanic(t *testing.T, name string, fn func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("%s: expected panic", name)
+		}
+	}()
+	fn()
+}
+
+// isolateRegistry 测试结束后恢复模块与错误码目录，-count=N 时可以重复注册同名模块
+func isolateRegistry(t *testing.T) {
+	t.Helper()
+	registryMu.Lock()
+	savedModules, savedCatalog := maps.Clone(modules), maps.Clone(catalog)
+	registryMu.Unlock()
+
+	t.Cleanup(func() {
+		registryMu.Lock()
+		modules, catalog = savedModules, savedCatalog
+		registryMu.Unlock()
+	})
+}
+
+func TestRegistry(t *testing.T) {
+	isolateRegistry(t)
+	order := NewModule("order", 31000, 31999)
+	errOrderClosed := order.Define(31001, 409, "Order Closed")
+
+	if e, ok := Lookup(31001); !ok || e.HttpStatus != errOrderClosed.HttpStatus {
+		t.Fatalf("lookup failed: %+v", e)
+	}
+
+	expectPanic(t, "duplicate code", func() { order.Define(31001, 400, "Dup") })
+	expectPanic(t, "out of range", func() { order.Define(32000, 400, "Out") })
+	expectPanic(t, "overlap", func() { NewModule("payment", 31500, 32500) })
+	expectPanic(t, "duplicate module", func() { NewModule("order", 40000, 40999) })
+
+	var buf bytes.Buffer
+	if err := ExportMarkdown(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "| 31001 | 409 Conflict | Order Closed |") || !strings.Contains(buf.String(), "## common") {
+		t.Fatalf("unexpected markdown:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := ExportOpenAPI(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"Error20001"`) {
+		t.Fatalf("unexpected openapi:\n%s", buf.String())
+	}
+}
@@ -10,3 +10,10 @@ type BaseResponse struct {
 	Error  *Error      `json:"error"`
 	Data   interface{} `json:"data" swaggertype:"object"`
 }
+
+// Response 带类型的 BaseResponse，JSON 格式与 BaseResponse 相同
+type Response[T any] struct {
+	Status string `json:"status" swaggertype:"string"`
+	Error  *Error `json:"error"`
+	Data   T      `json:"data"`
+}
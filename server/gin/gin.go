@@ -5,6 +5,8 @@ import (
 
 	"github.com/Yet-Another-AI-Project/kiwi-lib/logger"
 	"github.com/Yet-Another-AI-Project/kiwi-lib/server/gin/middleware"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/server/gin/utils"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -42,6 +44,8 @@ var (
 	}
 	metricsEndpoint string
 	serviceName     string
+	otelOptions     []middleware.OTelOption
+	prometheusPath  string
 )
 
 type Option func(*gin.Engine) error
@@ -60,6 +64,30 @@ func WithMetricsEndpoint(ep string) Option {
 	}
 }
 
+// WithTracing 设置 trace 导出、采样与 resource 属性，需同时设置 WithServiceName
+func WithTracing(trace otelutils.TraceConfig, res otelutils.ResourceConfig) Option {
+	return func(engine *gin.Engine) error {
+		otelOptions = append(otelOptions, middleware.WithTraceConfig(trace), middleware.WithResourceConfig(res))
+		return nil
+	}
+}
+
+// WithMetrics 设置指标导出，覆盖 WithMetricsEndpoint，需同时设置 WithServiceName
+func WithMetrics(cfg otelutils.MetricsConfig) Option {
+	return func(engine *gin.Engine) error {
+		otelOptions = append(otelOptions, middleware.WithMetricsConfig(cfg))
+		return nil
+	}
+}
+
+// WithPrometheus 在 path 上暴露 Prometheus 拉取指标，需在 WithMetrics 中启用 Prometheus
+func WithPrometheus(path string) Option {
+	return func(engine *gin.Engine) error {
+		prometheusPath = path
+		return nil
+	}
+}
+
 func WithLogger(logger logger.ILogger) Option {
 	return func(engine *gin.Engine) error {
 		engine.Use(ginlogger(logger))
@@ -67,7 +95,24 @@ func WithLogger(logger logger.ILogger) Option {
 	}
 }
 
+// WithErrorEncoder 设置 utils.ResponseError 的错误响应格式，如 utils.ProblemErrorEncoder
+func WithErrorEncoder(encoder utils.ErrorEncoder) Option {
+	return func(engine *gin.Engine) error {
+		engine.Use(func(c *gin.Context) {
+			c.Set(utils.ErrorEncoderKey, encoder)
+			c.Next()
+		})
+		return nil
+	}
+}
+
 func NewGin(options ...Option) (*gin.Engine, error) {
+	// 选项只对本次调用生效，出错返回时同样需要清理
+	defer func() {
+		otelOptions = nil
+		prometheusPath = ""
+	}()
+
 	engine := gin.New()
 	for _, option := range options {
 		if err := option(engine); err != nil {
@@ -82,13 +127,17 @@ func NewGin(options ...Option) (*gin.Engine, error) {
 	}))
 
 	if serviceName != "" {
-		handler, err := middleware.OpenTelemetryMiddleware(serviceName, metricsEndpoint)
+		handler, err := middleware.OpenTelemetryMiddleware(serviceName, metricsEndpoint, otelOptions...)
 		if err != nil {
 			return nil, err
 		}
 		engine.Use(handler)
 		engine.Use(middleware.ReponseTraceID())
 		engine.Use(middleware.MetricsMiddleware(serviceName))
+
+		if prometheusPath != "" {
+			engine.GET(prometheusPath, gin.WrapH(otelutils.PrometheusHandler()))
+		}
 	}
 
 	engine.ContextWithFallback = true
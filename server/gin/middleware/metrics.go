@@ -1,68 +1,252 @@
 package middleware
 
 import (
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
 )
 
+// otherValue 超过基数限制后的属性值
+const otherValue = "_other"
+
+var (
+	// DefaultDurationBuckets OTel HTTP 语义约定推荐的请求耗时桶，单位秒
+	DefaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10}
+	// DefaultSizeBuckets 请求、响应体大小的桶，单位字节
+	DefaultSizeBuckets = []float64{0, 100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+type metricsOptions struct {
+	meterProvider    metric.MeterProvider
+	durationBuckets  []float64
+	sizeBuckets      []float64
+	extractors       []extractor
+	cardinalityLimit int
+}
+
+type extractor struct {
+	key string
+	fn  func(c *gin.Context) string
+}
+
+type MetricsOption func(*metricsOptions)
+
+// WithMeterProvider 默认使用全局 MeterProvider
+func WithMeterProvider(provider metric.MeterProvider) MetricsOption {
+	return func(o *metricsOptions) {
+		o.meterProvider = provider
+	}
+}
+
+func WithDurationBuckets(buckets ...float64) MetricsOption {
+	return func(o *metricsOptions) {
+		o.durationBuckets = buckets
+	}
+}
+
+func WithSizeBuckets(buckets ...float64) MetricsOption {
+	return func(o *metricsOptions) {
+		o.sizeBuckets = buckets
+	}
+}
+
+// WithAttributeExtractor 为请求指标增加属性，如租户、API 版本，返回空字符串时不添加
+// 每个属性最多记录 WithCardinalityLimit 个不同的值，超过后记为 _other
+func WithAttributeExtractor(key string, fn func(c *gin.Context) string) MetricsOption {
+	return func(o *metricsOptions) {
+		o.extractors = append(o.extractors, extractor{key: key, fn: fn})
+	}
+}
+
+// WithCardinalityLimit 设置自定义属性的基数限制，默认 100
+func WithCardinalityLimit(limit int) MetricsOption {
+	return func(o *metricsOptions) {
+		o.cardinalityLimit = limit
+	}
+}
+
 type metrics struct {
-	requestCount metric.Int64Counter
-	responseTime metric.Float64Histogram
+	duration       metric.Float64Histogram
+	requestSize    metric.Int64Histogram
+	responseSize   metric.Int64Histogram
+	activeRequests metric.Int64UpDownCounter
 }
 
-func MetricsMiddleware(serviceName string) gin.HandlerFunc {
-	meter := otel.Meter("gin-middleware")
-	metrics := initMeter(meter)
+// MetricsMiddleware 按 OTel HTTP 语义约定记录 http.server.request.duration、
+// http.server.request.body.size、http.server.response.body.size 与 http.server.active_requests
+// serviceName 非空时作为 service 属性记录，兼容按 service 聚合的已有看板与告警
+func MetricsMiddleware(serviceName string, options ...MetricsOption) gin.HandlerFunc {
+	opts := metricsOptions{
+		durationBuckets:  DefaultDurationBuckets,
+		sizeBuckets:      DefaultSizeBuckets,
+		cardinalityLimit: 100,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.meterProvider == nil {
+		opts.meterProvider = otel.GetMeterProvider()
+	}
+
+	metrics := initMeter(opts.meterProvider.Meter("gin-middleware"), opts)
+	limiter := newCardinalityLimiter(opts.cardinalityLimit)
 
 	return func(c *gin.Context) {
 		start := time.Now()
+		ctx := c.Request.Context()
 
-		c.Next()
-
-		duration := time.Since(start).Seconds() * 1000
-
-		route := c.FullPath()
-		if route == "" {
-			route = "unknown"
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		active := []attribute.KeyValue{
+			attribute.String("http.request.method", c.Request.Method),
+			attribute.String("url.scheme", scheme),
+		}
+		if serviceName != "" {
+			active = append(active, attribute.String("service", serviceName))
 		}
+		activeAttrs := metric.WithAttributeSet(attribute.NewSet(active...))
+		metrics.activeRequests.Add(ctx, 1, activeAttrs)
+		defer metrics.activeRequests.Add(ctx, -1, activeAttrs)
 
+		c.Next()
+
+		status := c.Writer.Status()
 		labels := []attribute.KeyValue{
-			attribute.String("route", route),
-			attribute.String("method", c.Request.Method),
-			attribute.Int("status", c.Writer.Status()),
-			attribute.String("service", serviceName),
+			attribute.String("http.request.method", c.Request.Method),
+			attribute.Int("http.response.status_code", status),
+			attribute.String("url.scheme", scheme),
+			attribute.String("network.protocol.version", protocolVersion(c)),
+		}
+		if serviceName != "" {
+			labels = append(labels, attribute.String("service", serviceName))
+		}
+		// 未匹配路由时不记录 http.route，避免原始路径带来的高基数
+		if route := c.FullPath(); route != "" {
+			labels = append(labels, attribute.String("http.route", route))
 		}
+		if status >= 500 {
+			labels = append(labels, attribute.String("error.type", strconv.Itoa(status)))
+		}
+		for _, e := range opts.extractors {
+			if v := e.fn(c); v != "" {
+				labels = append(labels, attribute.String(e.key, limiter.limit(e.key, v)))
+			}
+		}
+		attrs := metric.WithAttributeSet(attribute.NewSet(labels...))
 
-		metrics.requestCount.Add(c.Request.Context(), 1, metric.WithAttributeSet(attribute.NewSet(labels...)))
-		metrics.responseTime.Record(c.Request.Context(), duration, metric.WithAttributeSet(attribute.NewSet(labels...)))
+		metrics.duration.Record(ctx, time.Since(start).Seconds(), attrs)
+		if c.Request.ContentLength >= 0 {
+			metrics.requestSize.Record(ctx, c.Request.ContentLength, attrs)
+		}
+		if size := c.Writer.Size(); size >= 0 {
+			metrics.responseSize.Record(ctx, int64(size), attrs)
+		}
 	}
 }
 
-func initMeter(meter metric.Meter) *metrics {
-	requestCount, err := meter.Int64Counter(
-		"http.request.count",
-		metric.WithDescription("Total number of requests"),
+// initMeter 创建失败时通过 otel.Handle 上报错误并使用 noop 指标，不影响请求处理
+func initMeter(meter metric.Meter, opts metricsOptions) *metrics {
+	fallback := noop.Meter{}
+
+	duration, err := meter.Float64Histogram(
+		"http.server.request.duration",
+		metric.WithDescription("Duration of HTTP server requests."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(opts.durationBuckets...),
+	)
+	if err != nil {
+		otel.Handle(err)
+		duration, _ = fallback.Float64Histogram("")
+	}
+
+	requestSize, err := meter.Int64Histogram(
+		"http.server.request.body.size",
+		metric.WithDescription("Size of HTTP server request bodies."),
+		metric.WithUnit("By"),
+		metric.WithExplicitBucketBoundaries(opts.sizeBuckets...),
+	)
+	if err != nil {
+		otel.Handle(err)
+		requestSize, _ = fallback.Int64Histogram("")
+	}
+
+	responseSize, err := meter.Int64Histogram(
+		"http.server.response.body.size",
+		metric.WithDescription("Size of HTTP server response bodies."),
+		metric.WithUnit("By"),
+		metric.WithExplicitBucketBoundaries(opts.sizeBuckets...),
 	)
 	if err != nil {
-		panic(err)
+		otel.Handle(err)
+		responseSize, _ = fallback.Int64Histogram("")
 	}
 
-	responseTime, err := meter.Float64Histogram(
-		"http.response.time.ms",
-		metric.WithDescription("Response time in ms"),
-		metric.WithUnit("ms"),
-		metric.WithExplicitBucketBoundaries(0, 10, 25, 50, 100, 250, 500, 1000, 2000, 5000, 10000, 30000, 60000, 120000),
+	activeRequests, err := meter.Int64UpDownCounter(
+		"http.server.active_requests",
+		metric.WithDescription("Number of active HTTP server requests."),
+		metric.WithUnit("{request}"),
 	)
 	if err != nil {
-		panic(err)
+		otel.Handle(err)
+		activeRequests, _ = fallback.Int64UpDownCounter("")
 	}
 
 	return &metrics{
-		requestCount: requestCount,
-		responseTime: responseTime,
+		duration:       duration,
+		requestSize:    requestSize,
+		responseSize:   responseSize,
+		activeRequests: activeRequests,
+	}
+}
+
+func protocolVersion(c *gin.Context) string {
+	return strconv.Itoa(c.Request.ProtoMajor) + "." + strconv.Itoa(c.Request.ProtoMinor)
+}
+
+// cardinalityLimiter 每个属性只保留前 max 个出现的值
+type cardinalityLimiter struct {
+	mu     sync.RWMutex
+	max    int
+	values map[string]map[string]struct{}
+}
+
+func newCardinalityLimiter(max int) *cardinalityLimiter {
+	return &cardinalityLimiter{
+		max:    max,
+		values: make(map[string]map[string]struct{}),
+	}
+}
+
+func (l *cardinalityLimiter) limit(key, value string) string {
+	l.mu.RLock()
+	_, ok := l.values[key][value]
+	l.mu.RUnlock()
+	if ok {
+		return value
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	seen, ok := l.values[key]
+	if !ok {
+		seen = make(map[string]struct{})
+		l.values[key] = seen
+	}
+	if _, ok := seen[value]; ok {
+		return value
+	}
+	if len(seen) >= l.max {
+		return otherValue
 	}
+	seen[value] = struct{}{}
+	return value
 }
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(MetricsMiddleware("demo",
+		WithMeterProvider(provider),
+		WithAttributeExtractor("tenant.id", func(c *gin.Context) string { return c.GetHeader("X-Tenant-Id") }),
+		WithCardinalityLimit(1),
+	))
+	engine.POST("/users/:id", func(c *gin.Context) {
+		c.String(http.StatusInternalServerError, "boom")
+	})
+
+	for _, tenant := range []string{"a", "b"} {
+		req := httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader("{}"))
+		req.Header.Set("X-Tenant-Id", tenant)
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+
+	found := map[string]metricdata.Metrics{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			found[m.Name] = m
+		}
+	}
+	for _, name := range []string{"http.server.request.duration", "http.server.request.body.size", "http.server.response.body.size", "http.server.active_requests"} {
+		if _, ok := found[name]; !ok {
+			t.Fatalf("missing metric %s", name)
+		}
+	}
+
+	duration := found["http.server.request.duration"]
+	if duration.Unit != "s" {
+		t.Fatalf("unexpected unit: %s", duration.Unit)
+	}
+
+	tenants := map[string]bool{}
+	for _, dp := range duration.Data.(metricdata.Histogram[float64]).DataPoints {
+		route, _ := dp.Attributes.Value(attribute.Key("http.route"))
+		errType, _ := dp.Attributes.Value(attribute.Key("error.type"))
+		service, _ := dp.Attributes.Value(attribute.Key("service"))
+		if route.AsString() != "/users/:id" || errType.AsString() != "500" || service.AsString() != "demo" {
+			t.Fatalf("unexpected attributes: %v", dp.Attributes.ToSlice())
+		}
+		tenant, _ := dp.Attributes.Value(attribute.Key("tenant.id"))
+		tenants[tenant.AsString()] = true
+	}
+	if !tenants["a"] || !tenants[otherValue] || len(tenants) != 2 {
+		t.Fatalf("unexpected tenants: %v", tenants)
+	}
+}
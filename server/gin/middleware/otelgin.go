@@ -2,106 +2,81 @@ package middleware
 
 import (
 	"context"
-	"time"
 
 	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/metric"
-	"go.opentelemetry.io/otel/sdk/resource"
-	"go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/propagation"
 )
 
-func OpenTelemetryMiddleware(serviceName string, endpoint string) (gin.HandlerFunc, error) {
-	if err := setupOTelSDK(serviceName, endpoint); err != nil {
-		return nil, err
-	}
-
-	return otelgin.Middleware(serviceName), nil
-}
+// legacyMetricsPath 只传 endpoint 时沿用的 Prometheus OTLP 接收路径
+const legacyMetricsPath = "/api/v1/otlp/v1/metrics"
 
-func ReponseTraceID() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		traceID := otelutils.GetTraceID(c)
+type OTelOption func(*otelutils.Config)
 
-		if traceID != "" {
-			c.Writer.Header().Set("x-trace-id", traceID)
-		}
-
-		c.Next()
+// WithTraceConfig 设置 trace 导出、批处理与采样，默认不导出，只用于生成 trace id
+func WithTraceConfig(cfg otelutils.TraceConfig) OTelOption {
+	return func(o *otelutils.Config) {
+		o.Trace = cfg
 	}
 }
 
-func setupOTelSDK(serviceName string, endpoint string) error {
-
-	res, err := resource.New(
-		context.Background(),
-		resource.WithAttributes(
-			semconv.ServiceNameKey.String(serviceName), // 设置服务名称
-		),
-	)
+// WithResourceConfig 设置服务版本、环境等 resource 属性，ServiceName 为空时使用 serviceName
+func WithResourceConfig(cfg otelutils.ResourceConfig) OTelOption {
+	return func(o *otelutils.Config) {
+		o.Resource = cfg
+	}
+}
 
-	if err != nil {
-		return nil
+// WithMetricsConfig 设置指标导出，覆盖 endpoint 参数
+func WithMetricsConfig(cfg otelutils.MetricsConfig) OTelOption {
+	return func(o *otelutils.Config) {
+		o.Metrics = cfg
 	}
+}
 
-	prop := newPropagator()
-	otel.SetTextMapPropagator(prop)
+// WithPropagator 设置全局 propagator，如 header.Propagator{} 以同时传递业务头部
+func WithPropagator(prop propagation.TextMapPropagator) OTelOption {
+	return func(o *otelutils.Config) {
+		o.Propagator = prop
+	}
+}
 
+// OpenTelemetryMiddleware 通过 otelutils.Setup 初始化全局 provider，退出前调用 otelutils.Shutdown
+// endpoint 非空且未设置 WithMetricsConfig 时，以明文 HTTP 推送到 Prometheus 的 OTLP 接收路径
+func OpenTelemetryMiddleware(serviceName string, endpoint string, options ...OTelOption) (gin.HandlerFunc, error) {
+	cfg := otelutils.Config{}
 	if endpoint != "" {
-		meterProvider, err := newMeterProvider(res, endpoint)
-		if err != nil {
-			return err
+		cfg.Metrics = otelutils.MetricsConfig{
+			Protocol: otelutils.ProtocolHTTP,
+			Endpoint: endpoint,
+			URLPath:  legacyMetricsPath,
+			Insecure: true,
 		}
-		otel.SetMeterProvider(meterProvider)
 	}
-
-	// 如果不设置 TraceProvider，无法生成TraceID
-	tracerProvider, err := newTraceProvider(res)
-	if err != nil {
-		return err
+	for _, option := range options {
+		option(&cfg)
+	}
+	if cfg.Resource.ServiceName == "" {
+		cfg.Resource.ServiceName = serviceName
 	}
-	otel.SetTracerProvider(tracerProvider)
-
-	return nil
-}
 
-func newMeterProvider(res *resource.Resource, endpoint string) (*metric.MeterProvider, error) {
-	metricExporter, err := otlpmetrichttp.New(context.Background(),
-		otlpmetrichttp.WithEndpoint(endpoint), // Prometheus 服务器的地址和端口
-		otlpmetrichttp.WithInsecure(),
-		otlpmetrichttp.WithURLPath("/api/v1/otlp/v1/metrics"), // OTLP 接收路径
-	)
-	if err != nil {
+	if _, err := otelutils.Setup(context.Background(), cfg); err != nil {
 		return nil, err
 	}
 
-	meterProvider := metric.NewMeterProvider(
-		metric.WithResource(res),
-		metric.WithReader(metric.NewPeriodicReader(
-			metricExporter,
-			metric.WithInterval(15*time.Second))),
-	)
-
-	return meterProvider, nil
+	return otelgin.Middleware(serviceName), nil
 }
 
-func newPropagator() propagation.TextMapPropagator {
-	return propagation.NewCompositeTextMapPropagator(
-		propagation.TraceContext{},
-		propagation.Baggage{},
-	)
-}
+func ReponseTraceID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		traceID := otelutils.GetTraceID(c)
 
-func newTraceProvider(res *resource.Resource) (*trace.TracerProvider, error) {
-	traceProvider := trace.NewTracerProvider(
-		trace.WithResource(res),
-		trace.WithSampler(trace.AlwaysSample()),
-	)
-	return traceProvider, nil
+		if traceID != "" {
+			c.Writer.Header().Set("x-trace-id", traceID)
+		}
+
+		c.Next()
+	}
 }
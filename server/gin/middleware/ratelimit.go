@@ -72,7 +72,7 @@ func RateLimit(config LimiterConfig, logger logger.ILogger) func(*gin.Context) {
 		}
 
 		if !allowed {
-			utils.ResponseError(c, facade.ErrTooManyRequests.Facade("操作太频繁，休息一下"))
+			utils.ResponseError(c, facade.ErrTooManyRequests)
 			return
 		}
 
@@ -0,0 +1,302 @@
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/logger"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/server/facade"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/server/gin/utils"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// EventMeta 连接建立后的第一个事件，携带 trace_id
+	EventMeta = "meta"
+	// EventError 错误事件，data 为 facade.Error
+	EventError = "error"
+	// EventDone 流结束事件
+	EventDone = "done"
+
+	// HeaderLastEventID 浏览器 EventSource 重连时携带的头部
+	HeaderLastEventID = "Last-Event-ID"
+)
+
+// ErrWriterClosed 客户端断开或 Writer 已关闭
+var ErrWriterClosed = errors.New("sse writer closed")
+
+var (
+	// fieldSanitizer id 与 event 字段中的换行会截断字段并注入新的字段，直接去掉
+	fieldSanitizer = strings.NewReplacer("\r", "", "\n", "", "\x00", "")
+	// lineNormalizer SSE 中 \r\n、\r、\n 都是行结束符，data 统一按 \n 拆分为多行
+	lineNormalizer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+)
+
+type Options struct {
+	// Heartbeat 心跳间隔，发送注释行防止代理断开空闲连接，<= 0 时关闭心跳
+	// 只有 Run 会发送心跳，Run 返回前关闭 Writer，心跳不会写入已被 gin 复用的响应
+	Heartbeat time.Duration
+	// Retry 建议客户端的重连间隔，随第一个事件下发
+	Retry time.Duration
+}
+
+type Option func(*Options)
+
+func WithHeartbeat(interval time.Duration) Option {
+	return func(o *Options) {
+		o.Heartbeat = interval
+	}
+}
+
+func WithRetry(retry time.Duration) Option {
+	return func(o *Options) {
+		o.Retry = retry
+	}
+}
+
+// Event 一个 SSE 事件，ID 为空时自动生成递增 id，ID 与 Event 中的换行符会被去掉
+// Data 为 string/[]byte 时原样输出，其余类型序列化为 JSON
+type Event struct {
+	ID    string
+	Event string
+	Data  any
+}
+
+// Writer 将 gin 响应转换为 text/event-stream，每个事件写入后立即 flush
+type Writer struct {
+	c *gin.Context
+	// ctx 创建时的请求 context，gin.Context 在 handler 返回后会被复用，不能在心跳中读取 c.Request
+	ctx     context.Context
+	opts    Options
+	mu      sync.Mutex
+	nextID  uint64
+	closed  bool
+	closeCh chan struct{}
+}
+
+// Run 创建 Writer 并执行 fn，fn 返回的错误以 error 事件发送，返回前关闭 Writer
+//
+//	r.GET("/chat", func(c *gin.Context) {
+//		sse.Run(c, func(w *sse.Writer) error {
+//			return w.Send("message", answer)
+//		})
+//	})
+func Run(c *gin.Context, fn func(w *Writer) error, options ...Option) {
+	w, err := NewWriter(c, options...)
+	if err != nil {
+		if !c.Writer.Written() {
+			utils.ResponseError(c, err)
+		}
+		return
+	}
+	defer w.Close()
+
+	if w.opts.Heartbeat > 0 {
+		go w.heartbeat()
+	}
+
+	if err := fn(w); err != nil {
+		_ = w.SendError(err)
+	}
+}
+
+// NewWriter 写入 SSE 响应头并发送携带 trace_id 的 meta 事件
+// 请求携带 Last-Event-ID 且为数字时，自动生成的 id 从其后继续递增
+// NewWriter 不发送心跳，调用方需在 handler 返回前 Close（或 Done），需要心跳时使用 Run
+func NewWriter(c *gin.Context, options ...Option) (*Writer, error) {
+	opts := Options{
+		Heartbeat: 15 * time.Second,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	if _, ok := c.Writer.(http.Flusher); !ok {
+		return nil, xerror.New("sse: response writer does not support flushing")
+	}
+
+	w := &Writer{
+		c:       c,
+		ctx:     c.Request.Context(),
+		opts:    opts,
+		closeCh: make(chan struct{}),
+	}
+	if id, err := strconv.ParseUint(LastEventID(c), 10, 64); err == nil {
+		w.nextID = id
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if err := w.send(Event{
+		Event: EventMeta,
+		Data:  map[string]string{"trace_id": otelutils.GetTraceID(c)},
+	}, true); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// LastEventID 返回客户端重连时携带的最后一个事件 id，兼容不支持自定义头部的 polyfill 使用 last_event_id 查询参数
+func LastEventID(c *gin.Context) string {
+	if id := c.GetHeader(HeaderLastEventID); id != "" {
+		return id
+	}
+	return c.Query("last_event_id")
+}
+
+// Context 返回请求的 context，客户端断开时 Done
+func (w *Writer) Context() context.Context {
+	return w.ctx
+}
+
+// Send 发送事件，event 为空时客户端按 message 事件处理
+func (w *Writer) Send(event string, data any) error {
+	return w.SendEvent(Event{Event: event, Data: data})
+}
+
+// SendEvent 发送事件并 flush，客户端断开后返回 ErrWriterClosed
+func (w *Writer) SendEvent(ev Event) error {
+	return w.send(ev, false)
+}
+
+// SendError 以 error 事件发送 facade.Error，err 的转换规则同 utils.ResponseError，e 为 nil 时按 ErrServerInternal 处理
+func (w *Writer) SendError(e error) error {
+	err := facade.FromError(e)
+	if err == nil {
+		err = facade.ErrServerInternal
+	}
+	if l, ok := w.c.Get("logger"); ok {
+		l.(logger.ILogger).Errorf(w.c, "SSE Stream Failed, path: %s, facade_message: %s, internal_error: %v, stacktrace: %s",
+			w.c.Request.URL.Path, err.FacadeMessage, err.InternalError, strings.Join(xerror.StackTrace(err.InternalError), "\n"))
+	}
+
+	return w.Send(EventError, err.Localize(utils.RequestLanguage(w.c)))
+}
+
+// Done 发送 done 事件并关闭 Writer
+func (w *Writer) Done() error {
+	err := w.Send(EventDone, "[DONE]")
+	w.Close()
+	return err
+}
+
+// Close 停止心跳，之后的发送返回 ErrWriterClosed，返回后不会再写入响应
+func (w *Writer) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.closed {
+		w.closed = true
+		close(w.closeCh)
+	}
+}
+
+func (w *Writer) send(ev Event, first bool) error {
+	if err := w.Context().Err(); err != nil {
+		w.Close()
+		return ErrWriterClosed
+	}
+
+	data, err := encodeData(ev.Data)
+	if err != nil {
+		return xerror.Wrap(err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+
+	if ev.ID == "" && !first {
+		w.nextID++
+		ev.ID = strconv.FormatUint(w.nextID, 10)
+	}
+
+	var sb strings.Builder
+	if first && w.opts.Retry > 0 {
+		fmt.Fprintf(&sb, "retry: %d\n", w.opts.Retry.Milliseconds())
+	}
+	if id := fieldSanitizer.Replace(ev.ID); id != "" {
+		fmt.Fprintf(&sb, "id: %s\n", id)
+	}
+	if event := fieldSanitizer.Replace(ev.Event); event != "" {
+		fmt.Fprintf(&sb, "event: %s\n", event)
+	}
+	for _, line := range strings.Split(lineNormalizer.Replace(data), "\n") {
+		fmt.Fprintf(&sb, "data: %s\n", line)
+	}
+	sb.WriteString("\n")
+
+	return w.write(sb.String())
+}
+
+// write 调用方需持有锁
+func (w *Writer) write(s string) error {
+	if _, err := io.WriteString(w.c.Writer, s); err != nil {
+		return ErrWriterClosed
+	}
+	w.c.Writer.Flush()
+	return nil
+}
+
+func (w *Writer) heartbeat() {
+	ticker := time.NewTicker(w.opts.Heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.closeCh:
+			return
+		case <-w.ctx.Done():
+			w.Close()
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			if w.closed {
+				w.mu.Unlock()
+				return
+			}
+			err := w.write(": ping\n\n")
+			w.mu.Unlock()
+			if err != nil {
+				w.Close()
+				return
+			}
+		}
+	}
+}
+
+func encodeData(data any) (string, error) {
+	switch v := data.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
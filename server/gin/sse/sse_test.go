@@ -0,0 +1,149 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/server/facade"
+	"github.com/gin-gonic/gin"
+)
+
+func TestWriter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/stream", func(c *gin.Context) {
+		w, err := NewWriter(c, WithHeartbeat(0))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer w.Close()
+
+		_ = w.Send("message", map[string]string{"answer": "hello"})
+		_ = w.Send("", "line1\nline2")
+		_ = w.SendError(facade.ErrTooManyRequests)
+		_ = w.Done()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+	req.Header.Set(HeaderLastEventID, "41")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected content type: %s", rec.Header().Get("Content-Type"))
+	}
+
+	want := "event: meta\ndata: {\"trace_id\":\"\"}\n\n" +
+		"id: 42\nevent: message\ndata: {\"answer\":\"hello\"}\n\n" +
+		"id: 43\ndata: line1\ndata: line2\n\n" +
+		"id: 44\nevent: error\ndata: {\"code\":20006,\"msg\":\"Too Many Requests\"}\n\n" +
+		"id: 45\nevent: done\ndata: [DONE]\n\n"
+	if rec.Body.String() != want {
+		t.Fatalf("unexpected body:\n%s", rec.Body.String())
+	}
+}
+
+func TestWriterSanitizesFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/stream", func(c *gin.Context) {
+		w, err := NewWriter(c, WithHeartbeat(0))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer w.Close()
+
+		_ = w.SendEvent(Event{ID: "1\ndata: injected", Event: "message\r\nretry: 1", Data: "a\rb\r\nc"})
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	want := "event: meta\ndata: {\"trace_id\":\"\"}\n\n" +
+		"id: 1data: injected\nevent: messageretry: 1\ndata: a\ndata: b\ndata: c\n\n"
+	if rec.Body.String() != want {
+		t.Fatalf("unexpected body:\n%q", rec.Body.String())
+	}
+}
+
+func TestRunHeartbeatStopsOnContextDone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	closed := make(chan bool, 1)
+	engine.GET("/stream", func(c *gin.Context) {
+		Run(c, func(w *Writer) error {
+			cancel()
+			deadline := time.Now().Add(time.Second)
+			done := false
+			for !done && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+				w.mu.Lock()
+				done = w.closed
+				w.mu.Unlock()
+			}
+			closed <- done
+			return nil
+		}, WithHeartbeat(5*time.Millisecond))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil).WithContext(ctx)
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !<-closed {
+		t.Fatal("writer should be closed after the request context is done")
+	}
+}
+
+func TestRunStopsWritingAfterReturn(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	var writer *Writer
+	engine.GET("/stream", func(c *gin.Context) {
+		Run(c, func(w *Writer) error {
+			writer = w
+			time.Sleep(5 * time.Millisecond)
+			return errors.New("boom")
+		}, WithHeartbeat(time.Millisecond))
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	body := rec.Body.String()
+
+	time.Sleep(10 * time.Millisecond)
+	if rec.Body.String() != body {
+		t.Fatalf("writer kept writing after Run returned:\n%q", rec.Body.String())
+	}
+	if err := writer.Send("message", "late"); !errors.Is(err, ErrWriterClosed) {
+		t.Fatalf("send after Run returned err = %v, want ErrWriterClosed", err)
+	}
+	if !strings.Contains(body, ": ping\n\n") || !strings.Contains(body, "event: error\ndata: {\"code\":20001") {
+		t.Fatalf("unexpected body:\n%q", body)
+	}
+}
+
+func TestWriterSendErrorNil(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/stream", func(c *gin.Context) {
+		Run(c, func(w *Writer) error {
+			return w.SendError(nil)
+		}, WithHeartbeat(0))
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	if !strings.Contains(rec.Body.String(), "event: error\ndata: {\"code\":20001") {
+		t.Fatalf("unexpected body:\n%q", rec.Body.String())
+	}
+}
@@ -0,0 +1,276 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/server/facade"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/text/language"
+)
+
+// Bind 绑定并校验请求参数，失败时已通过 ResponseError 响应 400，调用方直接 return 即可
+//
+//	req, ok := utils.Bind[CreateUserRequest](c)
+//	if !ok {
+//		return
+//	}
+func Bind[T any](c *gin.Context) (*T, bool) {
+	req, err := ShouldBind[T](c)
+	if err != nil {
+		ResponseError(c, err)
+		return nil, false
+	}
+	return req, true
+}
+
+// ShouldBind 依次从 JSON body、query（form 标签）、header（header 标签）、路径参数（uri 标签）绑定，
+// 后者覆盖前者，之后执行 validator v10 的 binding 规则校验
+// query、header、路径参数只绑定到显式声明了对应标签的字段；form 标签的 default 只在字段仍为零值时生效
+// 失败时返回携带字段级错误的 facade.ErrBadRequest，错误文案按请求语言本地化
+func ShouldBind[T any](c *gin.Context) (*T, error) {
+	req := new(T)
+	lang := RequestLanguage(c)
+
+	if err := bindBody(c, req); err != nil {
+		return nil, bindError(err, reflect.TypeFor[T](), lang)
+	}
+
+	if err := mapTagged(req, c.Request.URL.Query(), "form"); err != nil {
+		return nil, bindError(err, reflect.TypeFor[T](), lang)
+	}
+
+	if err := mapTagged(req, headerForm(c.Request.Header), "header"); err != nil {
+		return nil, bindError(err, reflect.TypeFor[T](), lang)
+	}
+
+	params := make(map[string][]string, len(c.Params))
+	for _, p := range c.Params {
+		params[p.Key] = []string{p.Value}
+	}
+	if err := mapTagged(req, params, "uri"); err != nil {
+		return nil, bindError(err, reflect.TypeFor[T](), lang)
+	}
+
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return nil, bindError(err, reflect.TypeFor[T](), lang)
+	}
+
+	return req, nil
+}
+
+// mapTagged 使用 gin 的 form 映射将 values 绑定到新的零值对象，再只复制带有 tag 标签且有对应值的字段，
+// 避免未加标签的字段按字段名被 query、header 覆盖；default 只填充仍为零值的字段
+func mapTagged(obj any, values map[string][]string, tag string) error {
+	dst := reflect.ValueOf(obj).Elem()
+	if dst.Kind() != reflect.Struct {
+		return binding.MapFormWithTag(obj, values, tag)
+	}
+
+	src := reflect.New(dst.Type())
+	if err := binding.MapFormWithTag(src.Interface(), values, tag); err != nil {
+		if fieldErr := locateField(dst.Type(), values, tag); fieldErr != nil {
+			return fieldErr
+		}
+		return err
+	}
+	mergeTagged(dst, src.Elem(), values, tag)
+	return nil
+}
+
+func mergeTagged(dst, src reflect.Value, values map[string][]string, tag string) {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			// 与 gin 一致，未加标签的结构体字段继续绑定其中带标签的字段
+			if field.Type.Kind() == reflect.Struct {
+				mergeTagged(dst.Field(i), src.Field(i), values, tag)
+			}
+			continue
+		}
+
+		_, present := values[name]
+		if present || (strings.Contains(opts, "default=") && dst.Field(i).IsZero()) {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
+
+// fieldBindError query、header 或路径参数的值无法转换为字段类型
+type fieldBindError struct {
+	Field string
+	Type  reflect.Type
+	Err   error
+}
+
+func (e *fieldBindError) Error() string {
+	return fmt.Sprintf("bind %s: %v", e.Field, e.Err)
+}
+
+func (e *fieldBindError) Unwrap() error {
+	return e.Err
+}
+
+// locateField gin 的映射错误不包含字段名，逐个字段单独映射找出值无法转换的字段，找不到时返回 nil
+func locateField(t reflect.Type, values map[string][]string, tag string) *fieldBindError {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			if field.Type.Kind() == reflect.Struct {
+				if err := locateField(field.Type, values, tag); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		value, ok := values[name]
+		if !ok {
+			continue
+		}
+		single := reflect.StructOf([]reflect.StructField{{Name: field.Name, Type: field.Type, Tag: field.Tag}})
+		if err := binding.MapFormWithTag(reflect.New(single).Interface(), map[string][]string{name: value}, tag); err != nil {
+			return &fieldBindError{Field: name, Type: field.Type, Err: err}
+		}
+	}
+	return nil
+}
+
+func bindBody(c *gin.Context, obj any) error {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil
+	}
+	if ct := c.ContentType(); ct != binding.MIMEJSON && ct != "" {
+		return nil
+	}
+
+	err := json.NewDecoder(c.Request.Body).Decode(obj)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+// bindError 将解析与校验错误转换为携带字段级错误的 facade.ErrBadRequest
+func bindError(err error, t reflect.Type, lang language.Tag) error {
+	var details []facade.FieldError
+
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	var fieldErr *fieldBindError
+
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, fe := range validationErrs {
+			field := fieldPath(t, fe.StructNamespace())
+			details = append(details, facade.FieldError{
+				Field:   field,
+				Rule:    fe.Tag(),
+				Message: ValidationMessage(lang, fe.Tag(), field, fe.Param()),
+			})
+		}
+	case errors.As(err, &typeErr):
+		details = append(details, facade.FieldError{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: ValidationMessage(lang, "type", typeErr.Field, typeErr.Type.String()),
+		})
+	case errors.As(err, &fieldErr):
+		details = append(details, facade.FieldError{
+			Field:   fieldErr.Field,
+			Rule:    "type",
+			Message: ValidationMessage(lang, "type", fieldErr.Field, fieldErr.Type.String()),
+		})
+	case errors.As(err, &syntaxErr):
+		details = append(details, facade.FieldError{
+			Rule:    "json",
+			Message: ValidationMessage(lang, "json", "", ""),
+		})
+	default:
+		return facade.ErrBadRequest.Wrap(err)
+	}
+
+	return facade.ErrBadRequest.WithDetails(details...).Wrap(xerror.WithKind(err, xerror.KindInvalidArgument))
+}
+
+// fieldPath 将校验错误的结构体命名空间转换为标签名路径，并去掉顶层结构体名，
+// 如 CreateUserRequest.Profile.Name -> profile.name
+// 在这里转换而不修改 gin 全局 validator 的 RegisterTagNameFunc，不影响其他调用方
+func fieldPath(t reflect.Type, namespace string) string {
+	parts := strings.Split(namespace, ".")
+	if len(parts) > 0 {
+		parts = parts[1:]
+	}
+
+	for i, part := range parts {
+		name, index, _ := strings.Cut(part, "[")
+		if index != "" {
+			index = "[" + index
+		}
+
+		// 指针、切片与 map 继续查找元素类型中的字段
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			continue
+		}
+		field, ok := t.FieldByName(name)
+		if !ok {
+			continue
+		}
+		parts[i] = tagName(field) + index
+		t = field.Type
+	}
+	return strings.Join(parts, ".")
+}
+
+// tagName 依次使用 json/form/uri/header 标签名，都没有时使用结构体字段名
+func tagName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri", "header"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// headerForm 同时以规范形式与小写形式作为 key，header 标签可以写作 X-User-Id 或 x-user-id
+func headerForm(h http.Header) map[string][]string {
+	form := make(map[string][]string, len(h)*2)
+	for k, v := range h {
+		form[k] = v
+		form[strings.ToLower(k)] = v
+	}
+	return form
+}
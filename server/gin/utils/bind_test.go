@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/server/facade"
+	"github.com/gin-gonic/gin"
+)
+
+type bindRequest struct {
+	ID      string `uri:"id" binding:"required,uuid"`
+	Page    int    `form:"page,default=1" binding:"gte=1"`
+	UserID  string `header:"X-User-Id" binding:"required"`
+	Name    string `json:"name" binding:"required,max=8"`
+	Profile struct {
+		Age int `json:"age" binding:"lte=150"`
+	} `json:"profile"`
+}
+
+func TestBind(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+
+	var bound *bindRequest
+	engine.POST("/users/:id", func(c *gin.Context) {
+		req, ok := Bind[bindRequest](c)
+		if !ok {
+			return
+		}
+		bound = req
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/7c9e6679-7425-40de-944b-e07fc1f90ae7?page=3", strings.NewReader(`{"name":"kiwi","profile":{"age":3}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-Id", "u1")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent || bound == nil || bound.Page != 3 || bound.UserID != "u1" || bound.Name != "kiwi" || bound.Profile.Age != 3 {
+		t.Fatalf("unexpected bind result: %d %+v", w.Code, bound)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader(`{"name":"too long name","profile":{"age":200}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "zh-CN")
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	var resp struct {
+		Error facade.Error `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest || resp.Error.Code != facade.ErrBadRequest.Code {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+
+	rules := make(map[string]string)
+	for _, d := range resp.Error.Details {
+		rules[d.Field] = d.Rule
+	}
+	want := map[string]string{"id": "uuid", "X-User-Id": "required", "name": "max", "profile.age": "lte"}
+	for field, rule := range want {
+		if rules[field] != rule {
+			t.Fatalf("field %s: got rule %q, want %q (%s)", field, rules[field], rule, w.Body.String())
+		}
+	}
+	if !strings.Contains(w.Body.String(), "name 最大为 8") {
+		t.Fatalf("message should be localized: %s", w.Body.String())
+	}
+}
+
+type bindPrecedenceRequest struct {
+	Origin string `json:"origin"`
+	Limit  int    `json:"limit" form:"limit,default=10"`
+	Offset int    `json:"offset" form:"offset,default=5"`
+	Items  []struct {
+		Name string `json:"name" binding:"required"`
+	} `json:"items" binding:"dive"`
+}
+
+func TestBindKeepsBodyValues(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newContext := func(body string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/?Origin=query", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Request.Header.Set("Origin", "https://evil.example")
+		return c
+	}
+
+	req, err := ShouldBind[bindPrecedenceRequest](newContext(`{"origin":"body","limit":50}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Origin != "body" || req.Limit != 50 || req.Offset != 5 {
+		t.Fatalf("unexpected bind result: %+v", req)
+	}
+
+	_, err = ShouldBind[bindPrecedenceRequest](newContext(`{"items":[{"name":"a"},{}]}`))
+	var fe *facade.Error
+	if !errors.As(err, &fe) || len(fe.Details) != 1 || fe.Details[0].Field != "items[1].name" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestBindTypeErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type request struct {
+		Page    int  `form:"page"`
+		Retries int  `header:"X-Retries"`
+		ID      uint `uri:"id"`
+	}
+
+	cases := []struct {
+		name   string
+		url    string
+		header string
+		params gin.Params
+		field  string
+	}{
+		{name: "query", url: "/?page=abc", field: "page"},
+		{name: "header", url: "/", header: "many", field: "X-Retries"},
+		{name: "uri", url: "/", params: gin.Params{{Key: "id", Value: "-1"}}, field: "id"},
+	}
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, tc.url, nil)
+		if tc.header != "" {
+			c.Request.Header.Set("X-Retries", tc.header)
+		}
+		c.Params = tc.params
+
+		_, err := ShouldBind[request](c)
+		var fe *facade.Error
+		if !errors.As(err, &fe) || fe.Code != facade.ErrBadRequest.Code || len(fe.Details) != 1 {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if d := fe.Details[0]; d.Field != tc.field || d.Rule != "type" {
+			t.Fatalf("%s: unexpected detail: %+v", tc.name, d)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"github.com/Yet-Another-AI-Project/kiwi-lib/server/facade"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorEncoderKey gin.Context 中保存 ErrorEncoder 的 key，由 futurxgin.WithErrorEncoder 注入
+const ErrorEncoderKey = "error_encoder"
+
+// ErrorEncoder 将 facade 错误写入响应并中断请求
+type ErrorEncoder func(c *gin.Context, err *facade.Error)
+
+// EnvelopeErrorEncoder 以 facade.BaseResponse{status, error, data} 格式响应
+func EnvelopeErrorEncoder(c *gin.Context, err *facade.Error) {
+	response := &facade.BaseResponse{
+		Status: facade.StatusError,
+		Error:  err,
+	}
+	c.AbortWithStatusJSON(err.StatusCode(), response)
+}
+
+// ProblemErrorEncoder 以 RFC 9457 application/problem+json 格式响应
+func ProblemErrorEncoder(c *gin.Context, err *facade.Error) {
+	problem := facade.NewProblemDetails(err, c.Request.URL.Path, otelutils.GetTraceID(c))
+	c.Header("Content-Type", facade.ContentTypeProblemJSON)
+	c.AbortWithStatusJSON(err.StatusCode(), problem)
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/server/facade"
+	"github.com/gin-gonic/gin"
+)
+
+func TestProblemErrorEncoder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set(ErrorEncoderKey, ErrorEncoder(ProblemErrorEncoder))
+		c.Next()
+	})
+	engine.GET("/users/:id", func(c *gin.Context) {
+		ResponseError(c, facade.ErrBadRequest.WithDetails(facade.FieldError{Field: "id", Rule: "uuid", Message: "id must be a uuid"}))
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	if w.Code != 400 || w.Header().Get("Content-Type") != facade.ContentTypeProblemJSON {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	var problem facade.ProblemDetails
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Type != "about:blank" || problem.Title != "Bad Request" || problem.Instance != "/users/1" ||
+		problem.Code != facade.ErrBadRequest.Code || len(problem.Errors) != 1 || problem.Errors[0].Field != "id" {
+		t.Fatalf("unexpected problem: %+v", problem)
+	}
+}
+
+func TestResponseErrorNil(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/", func(c *gin.Context) {
+		ResponseError(c, nil)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var resp facade.BaseResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != 500 || resp.Error == nil || resp.Error.Code != facade.ErrServerInternal.Code {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+}
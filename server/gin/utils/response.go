@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/server/facade"
+	"github.com/gin-gonic/gin"
+)
+
+// ResponseOK 以 facade.Response 格式响应成功
+func ResponseOK[T any](c *gin.Context, data T) {
+	c.JSON(http.StatusOK, &facade.Response[T]{
+		Status: facade.StatusSuccess,
+		Data:   data,
+	})
+}
+
+// ResponsePage 响应偏移分页结果，并设置 first/prev/next/last 的 Link 头部
+// pageNum 与 pageSize 一般来自 GetPageNumAndSize
+func ResponsePage[T any](c *gin.Context, list []T, pageNum, pageSize, total int) {
+	if list == nil {
+		list = []T{}
+	}
+
+	links := make(map[string]string)
+	if pageSize > 0 {
+		lastPage := (total + pageSize - 1) / pageSize
+		if lastPage < 1 {
+			lastPage = 1
+		}
+
+		links["first"] = "1"
+		links["last"] = strconv.Itoa(lastPage)
+		if pageNum > 1 {
+			links["prev"] = strconv.Itoa(pageNum - 1)
+		}
+		if pageNum < lastPage {
+			links["next"] = strconv.Itoa(pageNum + 1)
+		}
+	}
+	setLinkHeader(c, "page_num", links)
+
+	ResponseOK(c, &facade.PageResponse[T]{
+		List:     list,
+		PageNum:  pageNum,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// GetCursorAndSize 读取 cursor 与 page_size 查询参数，cursor 非空时解码到 v
+// 游标无效时返回 facade.ErrBadRequest，page_size 受 MaxPageSize 约束
+func GetCursorAndSize(c *gin.Context, codec *facade.CursorCodec, v any) (pageSize int, err error) {
+	_, pageSize = GetPageNumAndSize(c)
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		if err := codec.Decode(cursor, v); err != nil {
+			return 0, facade.ErrBadRequest.Wrap(err)
+		}
+	}
+
+	return pageSize, nil
+}
+
+// ResponseCursorPage 响应游标分页结果，next 为下一页游标内容（如最后一条记录的排序键），
+// 为 nil（包括 (*Cursor)(nil) 这样的空指针）表示没有更多数据；有下一页时设置 rel="next" 的 Link 头部
+func ResponseCursorPage[T any](c *gin.Context, codec *facade.CursorCodec, list []T, pageSize int, next any) {
+	if list == nil {
+		list = []T{}
+	}
+
+	page := &facade.CursorPageResponse[T]{
+		List:     list,
+		PageSize: pageSize,
+	}
+
+	if !isNil(next) {
+		cursor, err := codec.Encode(next)
+		if err != nil {
+			ResponseError(c, facade.ErrServerInternal.Wrap(err))
+			return
+		}
+		page.NextCursor = cursor
+		page.HasMore = true
+		setLinkHeader(c, "cursor", map[string]string{"next": cursor})
+	}
+
+	ResponseOK(c, page)
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// setLinkHeader 以当前请求 URL 为基础替换分页参数，生成 RFC 8288 Link 头部
+func setLinkHeader(c *gin.Context, param string, links map[string]string) {
+	if len(links) == 0 {
+		return
+	}
+
+	values := make([]string, 0, len(links))
+	for _, rel := range []string{"first", "prev", "next", "last"} {
+		v, ok := links[rel]
+		if !ok {
+			continue
+		}
+
+		u := url.URL{Path: c.Request.URL.Path}
+		query := c.Request.URL.Query()
+		query.Set(param, v)
+		u.RawQuery = query.Encode()
+		values = append(values, fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel))
+	}
+
+	c.Header("Link", strings.Join(values, ", "))
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/server/facade"
+	"github.com/gin-gonic/gin"
+)
+
+type itemCursor struct {
+	LastID int `json:"last_id"`
+}
+
+func TestResponseCursorPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	codec := facade.NewCursorCodec([]byte("secret"))
+	items := []int{1, 2, 3, 4, 5}
+
+	engine := gin.New()
+	engine.GET("/items", func(c *gin.Context) {
+		var cursor itemCursor
+		pageSize, err := GetCursorAndSize(c, codec, &cursor)
+		if err != nil {
+			ResponseError(c, err)
+			return
+		}
+
+		end := cursor.LastID + pageSize
+		if end >= len(items) {
+			var next *itemCursor
+			ResponseCursorPage(c, codec, items[cursor.LastID:], pageSize, next)
+			return
+		}
+		ResponseCursorPage(c, codec, items[cursor.LastID:end], pageSize, &itemCursor{LastID: end})
+	})
+
+	get := func(target string) (*httptest.ResponseRecorder, facade.Response[facade.CursorPageResponse[int]]) {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		var resp facade.Response[facade.CursorPageResponse[int]]
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	w, first := get("/items?page_size=3")
+	if !first.Data.HasMore || len(first.Data.List) != 3 || !strings.Contains(w.Header().Get("Link"), `rel="next"`) {
+		t.Fatalf("unexpected first page: %s %s", w.Header().Get("Link"), w.Body.String())
+	}
+
+	_, second := get("/items?page_size=3&cursor=" + first.Data.NextCursor)
+	if second.Data.HasMore || len(second.Data.List) != 2 || second.Data.List[0] != 4 {
+		t.Fatalf("unexpected second page: %+v", second.Data)
+	}
+
+	tampered := "eyJsYXN0X2lkIjowfQ." + strings.SplitN(first.Data.NextCursor, ".", 2)[1]
+	if w, _ := get("/items?cursor=" + tampered); w.Code != http.StatusBadRequest {
+		t.Fatalf("tampered cursor should be rejected, got %d", w.Code)
+	}
+}
+
+func TestResponsePageLink(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/items", func(c *gin.Context) {
+		pageNum, pageSize := GetPageNumAndSize(c)
+		ResponsePage(c, []string{"a"}, pageNum, pageSize, 25)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?page_num=2&page_size=10", nil))
+
+	link := w.Header().Get("Link")
+	for _, want := range []string{`page_num=1&page_size=10>; rel="first"`, `page_num=1&page_size=10>; rel="prev"`, `page_num=3&page_size=10>; rel="next"`, `page_num=3&page_size=10>; rel="last"`} {
+		if !strings.Contains(link, want) {
+			t.Fatalf("link %q should contain %q", link, want)
+		}
+	}
+}
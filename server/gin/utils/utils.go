@@ -2,32 +2,58 @@ package utils
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/Yet-Another-AI-Project/kiwi-lib/logger"
 	"github.com/Yet-Another-AI-Project/kiwi-lib/server/facade"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/header"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
 	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
 )
 
-func ResponseWebSocketError(c *gin.Context, err *facade.Error) {
+// ResponseWebSocketError 记录 websocket 请求的错误，err 的转换规则同 ResponseError
+// 只记录日志，需要向客户端发送错误时使用 ws.Conn 的 SendError 或 CloseWithError
+func ResponseWebSocketError(c *gin.Context, e error) {
+	err := facade.FromError(e)
 	path := c.Request.URL.Path
 	futurxLogger, ok := c.Get("logger")
 	if ok {
-		futurxLogger.(logger.ILogger).Errorf(c, "Gin Request Failed, path: %s, facade_message: %s, internal_error: %v", path, err.FacadeMessage, err.InternalError)
+		futurxLogger.(logger.ILogger).Errorf(c, "Gin Request Failed, path: %s, facade_message: %s, internal_error: %v, stacktrace: %s", path, err.FacadeMessage, err.InternalError, strings.Join(xerror.StackTrace(err.InternalError), "\n"))
 	}
 }
 
-func ResponseError(c *gin.Context, err *facade.Error) {
-	response := &facade.BaseResponse{}
+// ResponseError 响应错误，默认使用 facade.BaseResponse 格式，可通过 ErrorEncoderKey 切换
+// e 可以是任意错误，通过 facade.FromError 按错误链匹配 HTTP 状态码与错误码，e 为 nil 时按 ErrServerInternal 处理
+func ResponseError(c *gin.Context, e error) {
+	err := facade.FromError(e)
+	if err == nil {
+		err = facade.ErrServerInternal
+	}
 	path := c.Request.URL.Path
 	futurxLogger, ok := c.Get("logger")
 	if ok {
-		futurxLogger.(logger.ILogger).Errorf(c, "Gin Request Failed, path: %s, facade_message: %s, internal_error: %v", path, err.FacadeMessage, err.InternalError)
+		futurxLogger.(logger.ILogger).Errorf(c, "Gin Request Failed, path: %s, facade_message: %s, internal_error: %v, stacktrace: %s", path, err.FacadeMessage, err.InternalError, strings.Join(xerror.StackTrace(err.InternalError), "\n"))
+	}
+	encoder := EnvelopeErrorEncoder
+	if v, ok := c.Get(ErrorEncoderKey); ok {
+		encoder = v.(ErrorEncoder)
 	}
-	response.Status = "error"
-	response.Error = err
-	c.AbortWithStatusJSON(err.StatusCode(), response)
+	encoder(c, err.Localize(RequestLanguage(c)))
+}
+
+// RequestLanguage 依次根据 Accept-Language 与透传的 Content-Language 头部选择响应语言
+func RequestLanguage(c *gin.Context) language.Tag {
+	return facade.MatchLanguage(
+		c.GetHeader("Accept-Language"),
+		header.GetPropagatedHeader(c.Request.Context(), header.HeaderContentLanguage),
+		c.GetHeader(header.HeaderContentLanguage),
+	)
 }
 
+// MaxPageSize 分页参数 page_size 的上限，超过时按上限处理，默认 0 表示不限制，需要时在启动时设置
+var MaxPageSize = 0
+
 func GetPageNumAndSize(ctx *gin.Context) (pageNum, pageSize int) {
 
 	pageNum, pageSize = 1, 10
@@ -47,5 +73,9 @@ func GetPageNumAndSize(ctx *gin.Context) (pageNum, pageSize int) {
 		}
 	}
 
+	if MaxPageSize > 0 && pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
 	return
 }
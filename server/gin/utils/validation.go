@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+var (
+	validationMu sync.RWMutex
+
+	// validationMessages 语言 -> 校验规则 -> 文案模板，支持 {field} 与 {param} 占位符
+	validationMessages = map[language.Tag]map[string]string{
+		language.English: {
+			"required": "{field} is required",
+			"min":      "{field} must be at least {param}",
+			"max":      "{field} must be at most {param}",
+			"len":      "{field} must have length {param}",
+			"gte":      "{field} must be greater than or equal to {param}",
+			"lte":      "{field} must be less than or equal to {param}",
+			"gt":       "{field} must be greater than {param}",
+			"lt":       "{field} must be less than {param}",
+			"oneof":    "{field} must be one of [{param}]",
+			"email":    "{field} must be a valid email address",
+			"url":      "{field} must be a valid URL",
+			"uuid":     "{field} must be a valid UUID",
+			"type":     "{field} must be of type {param}",
+			"json":     "request body is not valid JSON",
+			"default":  "{field} failed on the '{rule}' rule",
+		},
+		language.Chinese: {
+			"required": "{field} 不能为空",
+			"min":      "{field} 最小为 {param}",
+			"max":      "{field} 最大为 {param}",
+			"len":      "{field} 长度必须为 {param}",
+			"gte":      "{field} 必须大于或等于 {param}",
+			"lte":      "{field} 必须小于或等于 {param}",
+			"gt":       "{field} 必须大于 {param}",
+			"lt":       "{field} 必须小于 {param}",
+			"oneof":    "{field} 必须是 [{param}] 中的一个",
+			"email":    "{field} 必须是合法的邮箱地址",
+			"url":      "{field} 必须是合法的 URL",
+			"uuid":     "{field} 必须是合法的 UUID",
+			"type":     "{field} 类型必须为 {param}",
+			"json":     "请求体不是合法的 JSON",
+			"default":  "{field} 未通过 '{rule}' 规则校验",
+		},
+	}
+)
+
+// RegisterValidationMessages 注册或覆盖某个语言下校验规则的文案，如自定义校验规则的文案
+func RegisterValidationMessages(lang language.Tag, msgs map[string]string) {
+	validationMu.Lock()
+	defer validationMu.Unlock()
+
+	catalog, ok := validationMessages[lang]
+	if !ok {
+		catalog = make(map[string]string, len(msgs))
+		validationMessages[lang] = catalog
+	}
+	for rule, msg := range msgs {
+		catalog[rule] = msg
+	}
+}
+
+// ValidationMessage 返回校验规则在指定语言下的文案，找不到语言时使用英文
+func ValidationMessage(lang language.Tag, rule, field, param string) string {
+	validationMu.RLock()
+	catalog, ok := validationMessages[lang]
+	if !ok {
+		catalog = validationMessages[language.English]
+	}
+	tmpl, ok := catalog[rule]
+	if !ok {
+		tmpl = catalog["default"]
+	}
+	validationMu.RUnlock()
+
+	return strings.NewReplacer("{field}", field, "{param}", param, "{rule}", rule).Replace(tmpl)
+}
@@ -0,0 +1,518 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/logger"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/server/facade"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/server/gin/utils"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/header"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/limiter"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/text/language"
+)
+
+// maxCloseReason websocket close frame 中 reason 的最大字节数
+const maxCloseReason = 123
+
+var (
+	// ErrConnClosed 连接已关闭
+	ErrConnClosed = errors.New("websocket connection closed")
+	// ErrSendBufferFull TrySend 时发送队列已满
+	ErrSendBufferFull = errors.New("websocket send buffer full")
+)
+
+type Options struct {
+	// ReadLimit 单条消息的最大字节数，超过时以 1009 关闭连接
+	ReadLimit int64
+	// PingInterval 服务端发送 ping 的间隔，需小于 PongWait
+	PingInterval time.Duration
+	// PongWait 等待 pong（或任意消息）的超时时间
+	PongWait time.Duration
+	// WriteWait 单次写入的超时时间
+	WriteWait time.Duration
+	// SendBuffer 发送队列长度，队列满时 Send 阻塞，TrySend 返回 ErrSendBufferFull
+	SendBuffer int
+	// AllowedOrigins 允许的 Origin，支持 "*" 与 "*.example.com"，为空时只允许同源
+	AllowedOrigins []string
+	// Authenticate 升级前的鉴权，返回错误时以 HTTP 响应错误而不升级
+	Authenticate func(c *gin.Context) error
+	// Limiter 连接数限制，如 limiter.NewMemoryLimiter、limiter.NewRedisLimiter
+	// 与 middleware.NewLimiter 效果相同，Handle 在连接期间阻塞，也可以直接在路由上使用该中间件
+	Limiter      limiter.Limiter
+	Subprotocols []string
+}
+
+type Option func(*Options)
+
+func WithReadLimit(limit int64) Option {
+	return func(o *Options) {
+		o.ReadLimit = limit
+	}
+}
+
+func WithKeepalive(pingInterval, pongWait time.Duration) Option {
+	return func(o *Options) {
+		o.PingInterval = pingInterval
+		o.PongWait = pongWait
+	}
+}
+
+func WithWriteWait(writeWait time.Duration) Option {
+	return func(o *Options) {
+		o.WriteWait = writeWait
+	}
+}
+
+func WithSendBuffer(size int) Option {
+	return func(o *Options) {
+		o.SendBuffer = size
+	}
+}
+
+func WithAllowedOrigins(origins ...string) Option {
+	return func(o *Options) {
+		o.AllowedOrigins = origins
+	}
+}
+
+func WithAuthenticate(fn func(c *gin.Context) error) Option {
+	return func(o *Options) {
+		o.Authenticate = fn
+	}
+}
+
+func WithLimiter(l limiter.Limiter) Option {
+	return func(o *Options) {
+		o.Limiter = l
+	}
+}
+
+func WithSubprotocols(protocols ...string) Option {
+	return func(o *Options) {
+		o.Subprotocols = protocols
+	}
+}
+
+// Handler 处理一个 websocket 连接，返回时连接关闭；返回的错误以 close frame 发送给客户端
+type Handler func(conn *Conn) error
+
+// Message 客户端发送的消息
+type Message struct {
+	Type int
+	Data []byte
+}
+
+type outbound struct {
+	messageType int
+	data        []byte
+}
+
+// Conn 对 websocket.Conn 的封装，读写分别由独立的 pump goroutine 完成
+type Conn struct {
+	ws   *websocket.Conn
+	gin  *gin.Context
+	opts Options
+	ctx  context.Context
+	span trace.Span
+	lang language.Tag
+
+	in   chan Message
+	send chan outbound
+
+	closeOnce  sync.Once
+	cancel     context.CancelFunc
+	closeCode  int
+	closeText  string
+	closed     chan struct{}
+	writerDone chan struct{}
+
+	mu       sync.Mutex
+	received int64
+	sent     int64
+}
+
+// Handle 返回升级 websocket 的 gin handler
+// 依次执行连接数限制、鉴权、Origin 校验后升级，连接期间记录一个 OTel span
+func Handle(handler Handler, options ...Option) gin.HandlerFunc {
+	opts := Options{
+		ReadLimit:    1 << 20,
+		PingInterval: 30 * time.Second,
+		PongWait:     60 * time.Second,
+		WriteWait:    10 * time.Second,
+		SendBuffer:   64,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin:  checkOrigin(opts.AllowedOrigins),
+		Subprotocols: opts.Subprotocols,
+	}
+
+	return func(c *gin.Context) {
+		if opts.Limiter != nil {
+			ok, err := opts.Limiter.Acquire()
+			if err != nil {
+				utils.ResponseError(c, facade.ErrServerInternal.Wrap(err))
+				return
+			}
+			if !ok {
+				utils.ResponseError(c, facade.ErrTooManyRequests)
+				return
+			}
+			defer opts.Limiter.Release()
+		}
+
+		if opts.Authenticate != nil {
+			if err := opts.Authenticate(c); err != nil {
+				utils.ResponseError(c, err)
+				return
+			}
+		}
+
+		if !upgrader.CheckOrigin(c.Request) {
+			utils.ResponseError(c, facade.ErrForbidden.Facade("origin not allowed"))
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		ctx, span := otel.Tracer("kiwi-lib/ws").Start(c.Request.Context(), "websocket "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.route", route),
+				attribute.String("client.address", c.ClientIP()),
+			),
+		)
+
+		// 握手请求中的业务头部与 baggage 在连接的 context 中可用，如 header.UserID(conn.Context())
+		ctx = header.ExtractHeadersToContext(ctx, c.Request)
+
+		wsConn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// Upgrade 失败时已写入 HTTP 错误响应
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
+			utils.ResponseWebSocketError(c, facade.ErrBadRequest.Wrap(err))
+			return
+		}
+
+		conn := newConn(ctx, c, wsConn, opts, span)
+		conn.serve(handler)
+	}
+}
+
+func newConn(ctx context.Context, c *gin.Context, wsConn *websocket.Conn, opts Options, span trace.Span) *Conn {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Conn{
+		ws:         wsConn,
+		gin:        c,
+		opts:       opts,
+		ctx:        ctx,
+		span:       span,
+		lang:       utils.RequestLanguage(c),
+		in:         make(chan Message),
+		send:       make(chan outbound, opts.SendBuffer),
+		cancel:     cancel,
+		closeCode:  websocket.CloseNormalClosure,
+		closed:     make(chan struct{}),
+		writerDone: make(chan struct{}),
+	}
+}
+
+func (c *Conn) serve(handler Handler) {
+	go c.writePump()
+	go c.readPump()
+
+	err := c.runHandler(handler)
+	if err != nil {
+		c.CloseWithError(err)
+	} else {
+		c.Close()
+	}
+	<-c.writerDone
+
+	c.mu.Lock()
+	c.span.SetAttributes(
+		attribute.Int64("websocket.messages.received", c.received),
+		attribute.Int64("websocket.messages.sent", c.sent),
+		attribute.Int("websocket.close.code", c.closeCode),
+	)
+	c.mu.Unlock()
+	c.span.End()
+}
+
+func (c *Conn) runHandler(handler Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = xerror.New("websocket handler panic")
+			if l, ok := c.gin.Get("logger"); ok {
+				l.(logger.ILogger).Errorf(c.ctx, "websocket handler recovered %v", r)
+			}
+		}
+	}()
+	return handler(c)
+}
+
+// Context 连接关闭时 Done，携带连接的 span
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// GinContext 返回升级时的 gin.Context，仅可用于读取请求信息与 c.Get
+func (c *Conn) GinContext() *gin.Context {
+	return c.gin
+}
+
+// Messages 返回客户端消息，连接关闭后 channel 关闭
+func (c *Conn) Messages() <-chan Message {
+	return c.in
+}
+
+// Send 将消息放入发送队列，队列满时阻塞直到有空位、ctx 结束或连接关闭
+func (c *Conn) Send(ctx context.Context, messageType int, data []byte) error {
+	select {
+	case <-c.closed:
+		return ErrConnClosed
+	default:
+	}
+
+	select {
+	case c.send <- outbound{messageType: messageType, data: data}:
+		return nil
+	case <-c.closed:
+		return ErrConnClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TrySend 非阻塞发送，队列满时返回 ErrSendBufferFull，调用方可据此丢弃或断开慢客户端
+func (c *Conn) TrySend(messageType int, data []byte) error {
+	select {
+	case <-c.closed:
+		return ErrConnClosed
+	default:
+	}
+
+	select {
+	case c.send <- outbound{messageType: messageType, data: data}:
+		return nil
+	case <-c.closed:
+		return ErrConnClosed
+	default:
+		return ErrSendBufferFull
+	}
+}
+
+// SendJSON 以文本消息发送 v 的 JSON
+func (c *Conn) SendJSON(ctx context.Context, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return xerror.Wrap(err)
+	}
+	return c.Send(ctx, websocket.TextMessage, data)
+}
+
+// ErrorMessage 以消息形式发送给客户端的错误
+type ErrorMessage struct {
+	Type  string        `json:"type"`
+	Error *facade.Error `json:"error"`
+}
+
+// SendError 以 {"type": "error", "error": {...}} 消息发送错误而不关闭连接，err 的转换规则同 utils.ResponseError，e 为 nil 时按 ErrServerInternal 处理
+func (c *Conn) SendError(ctx context.Context, e error) error {
+	err := c.logError(e)
+	return c.SendJSON(ctx, &ErrorMessage{Type: "error", Error: err.Localize(c.lang)})
+}
+
+// CloseWithError 以 close frame 发送错误并关闭连接
+// 4xx 错误使用 4000 + HTTP 状态码作为关闭码（如 4401、4429），5xx 使用 1011，reason 为 facade.Error 的 JSON
+func (c *Conn) CloseWithError(e error) {
+	err := c.logError(e)
+	code := websocket.CloseInternalServerErr
+	if err.HttpStatus >= 400 && err.HttpStatus < 500 {
+		code = 4000 + err.HttpStatus
+	}
+
+	c.span.RecordError(e)
+	c.span.SetStatus(codes.Error, err.FacadeMessage)
+	c.closeWith(code, closeReason(err.Localize(c.lang)))
+}
+
+// Close 以 1000 正常关闭连接
+func (c *Conn) Close() {
+	c.closeWith(websocket.CloseNormalClosure, "")
+}
+
+func (c *Conn) closeWith(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.closeCode = code
+		c.closeText = reason
+		c.mu.Unlock()
+		close(c.closed)
+		c.cancel()
+	})
+}
+
+// logError e 为 nil 时按 ErrServerInternal 处理，同 utils.ResponseError
+func (c *Conn) logError(e error) *facade.Error {
+	err := facade.FromError(e)
+	if err == nil {
+		err = facade.ErrServerInternal
+	}
+	if l, ok := c.gin.Get("logger"); ok {
+		l.(logger.ILogger).Errorf(c.ctx, "WebSocket Failed, path: %s, facade_message: %s, internal_error: %v, stacktrace: %s",
+			c.gin.Request.URL.Path, err.FacadeMessage, err.InternalError, strings.Join(xerror.StackTrace(err.InternalError), "\n"))
+	}
+	return err
+}
+
+func (c *Conn) readPump() {
+	defer close(c.in)
+
+	c.ws.SetReadLimit(c.opts.ReadLimit)
+	_ = c.ws.SetReadDeadline(time.Now().Add(c.opts.PongWait))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(c.opts.PongWait))
+	})
+
+	for {
+		messageType, data, err := c.ws.ReadMessage()
+		if err != nil {
+			switch {
+			case errors.Is(err, websocket.ErrReadLimit):
+				c.closeWith(websocket.CloseMessageTooBig, "message too big")
+			case websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived):
+				c.Close()
+			default:
+				c.closeWith(websocket.CloseGoingAway, "")
+			}
+			return
+		}
+		_ = c.ws.SetReadDeadline(time.Now().Add(c.opts.PongWait))
+
+		c.mu.Lock()
+		c.received++
+		c.mu.Unlock()
+
+		// 处理方消费慢时阻塞读取，由 TCP 向客户端施加背压
+		select {
+		case c.in <- Message{Type: messageType, Data: data}:
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+func (c *Conn) writePump() {
+	ticker := time.NewTicker(c.opts.PingInterval)
+	defer func() {
+		ticker.Stop()
+		c.ws.Close()
+		close(c.writerDone)
+	}()
+
+	for {
+		select {
+		case msg := <-c.send:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(c.opts.WriteWait))
+			if err := c.ws.WriteMessage(msg.messageType, msg.data); err != nil {
+				c.closeWith(websocket.CloseGoingAway, "")
+				return
+			}
+			c.mu.Lock()
+			c.sent++
+			c.mu.Unlock()
+		case <-ticker.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.opts.WriteWait)); err != nil {
+				c.closeWith(websocket.CloseGoingAway, "")
+				return
+			}
+		case <-c.closed:
+			c.drain()
+			c.mu.Lock()
+			code, reason := c.closeCode, c.closeText
+			c.mu.Unlock()
+			_ = c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(c.opts.WriteWait))
+			return
+		}
+	}
+}
+
+// drain 关闭前尽量发送队列中剩余的消息，如 SendError 之后紧接着关闭
+func (c *Conn) drain() {
+	for {
+		select {
+		case msg := <-c.send:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(c.opts.WriteWait))
+			if err := c.ws.WriteMessage(msg.messageType, msg.data); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// closeReason 将 facade 错误编码为 close reason，超长时只保留错误码
+func closeReason(err *facade.Error) string {
+	data, _ := json.Marshal(err)
+	if len(data) <= maxCloseReason {
+		return string(data)
+	}
+	data, _ = json.Marshal(map[string]int{"code": err.Code})
+	return string(data)
+}
+
+// checkOrigin 未配置时只允许同源请求或无 Origin 的非浏览器客户端
+func checkOrigin(allowed []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+
+		if len(allowed) == 0 {
+			return strings.EqualFold(u.Host, r.Host)
+		}
+
+		for _, pattern := range allowed {
+			switch {
+			case pattern == "*":
+				return true
+			case strings.HasPrefix(pattern, "*."):
+				if strings.HasSuffix(strings.ToLower(u.Hostname()), strings.ToLower(pattern[1:])) {
+					return true
+				}
+			case strings.EqualFold(pattern, origin) || strings.EqualFold(pattern, u.Host):
+				return true
+			}
+		}
+		return false
+	}
+}
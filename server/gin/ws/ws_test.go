@@ -0,0 +1,143 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/server/facade"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/limiter"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func newServer(t *testing.T, handler Handler, options ...Option) string {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/ws", Handle(handler, options...))
+
+	srv := httptest.NewServer(engine)
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+}
+
+func TestEchoAndCloseWithError(t *testing.T) {
+	url := newServer(t, func(conn *Conn) error {
+		for msg := range conn.Messages() {
+			if string(msg.Data) == "quit" {
+				return facade.ErrTooManyRequests
+			}
+			if err := conn.Send(conn.Context(), msg.Type, msg.Data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	client, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	_ = client.WriteMessage(websocket.TextMessage, []byte("hello"))
+	_, data, err := client.ReadMessage()
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("unexpected echo: %s, %v", data, err)
+	}
+
+	_ = client.WriteMessage(websocket.TextMessage, []byte("quit"))
+	_, _, err = client.ReadMessage()
+
+	closeErr, ok := err.(*websocket.CloseError)
+	if !ok || closeErr.Code != 4429 {
+		t.Fatalf("unexpected close: %v", err)
+	}
+	var e facade.Error
+	if err := json.Unmarshal([]byte(closeErr.Text), &e); err != nil || e.Code != 20006 {
+		t.Fatalf("unexpected close reason: %q", closeErr.Text)
+	}
+}
+
+func TestSendErrorAndReadLimit(t *testing.T) {
+	url := newServer(t, func(conn *Conn) error {
+		_ = conn.SendError(context.Background(), facade.ErrBadRequest)
+		for range conn.Messages() {
+		}
+		return nil
+	}, WithReadLimit(8))
+
+	client, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var msg ErrorMessage
+	if err := client.ReadJSON(&msg); err != nil || msg.Type != "error" || msg.Error.Code != 20002 {
+		t.Fatalf("unexpected error message: %+v, %v", msg, err)
+	}
+
+	_ = client.WriteMessage(websocket.TextMessage, []byte("this message is too long"))
+	_, _, err = client.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Fatalf("unexpected close: %v", err)
+	}
+}
+
+func TestNilError(t *testing.T) {
+	url := newServer(t, func(conn *Conn) error {
+		_ = conn.SendError(context.Background(), nil)
+		conn.CloseWithError(nil)
+		return nil
+	})
+
+	client, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var msg ErrorMessage
+	if err := client.ReadJSON(&msg); err != nil || msg.Error.Code != 20001 {
+		t.Fatalf("unexpected error message: %+v, %v", msg, err)
+	}
+	_, _, err = client.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseInternalServerErr) {
+		t.Fatalf("unexpected close: %v", err)
+	}
+}
+
+func TestRejectBeforeUpgrade(t *testing.T) {
+	url := newServer(t, func(conn *Conn) error {
+		<-conn.Context().Done()
+		return nil
+	}, WithLimiter(limiter.NewMemoryLimiter(1)))
+
+	first, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected connection cap, got %v", err)
+	}
+
+	url = newServer(t, func(conn *Conn) error { return nil }, WithAllowedOrigins("*.example.com"))
+	header := http.Header{"Origin": {"https://evil.com"}}
+	_, resp, err = websocket.DefaultDialer.Dial(url, header)
+	if err == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected origin rejection, got %v", err)
+	}
+	header.Set("Origin", "https://app.example.com")
+	allowed, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowed.Close()
+}
@@ -0,0 +1,484 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// State 熔断器状态
+type State int
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+// Outcome 请求结果
+type Outcome int
+
+const (
+	OutcomeSuccess Outcome = iota
+	OutcomeFailure
+	// OutcomeIgnored 不计入统计，半开状态下释放探测名额
+	OutcomeIgnored
+)
+
+type Options struct {
+	// Window 与 Buckets 滚动统计窗口的长度与分桶数，默认 10s、10 个桶
+	Window  time.Duration
+	Buckets int
+	// MinRequests 窗口内请求数达到该值后才判断是否熔断，默认 20
+	MinRequests int
+	// ErrorRate 失败率阈值，达到后熔断，默认 0.5
+	ErrorRate float64
+	// SlowCallDuration 耗时超过该值的请求记为慢调用，为 0 时不统计
+	SlowCallDuration time.Duration
+	// SlowCallRate 慢调用比例阈值，达到后熔断，默认 1 即所有请求都为慢调用时才熔断
+	SlowCallRate float64
+	// OpenTimeout 打开状态持续的时间，之后进入半开状态，默认 30s
+	OpenTimeout time.Duration
+	// HalfOpenMaxRequests 半开状态允许的探测请求数，全部成功后关闭熔断器，默认 1
+	HalfOpenMaxRequests int
+	// IsFailure 判断调用结果是否记为失败，默认 err 不为 nil
+	IsFailure func(err error) bool
+	// IsIgnored 判断调用结果是否不计入统计，优先于 IsFailure，默认 err 为 context.Canceled
+	IsIgnored func(err error) bool
+	// OnStateChange 状态变化时回调，回调时持有熔断器的锁，不能在回调中调用 Breaker 的方法
+	OnStateChange func(name string, from, to State)
+
+	Logger        logger.ILogger
+	MeterProvider metric.MeterProvider
+}
+
+type Option func(*Options)
+
+// WithWindow 设置滚动统计窗口
+func WithWindow(window time.Duration, buckets int) Option {
+	return func(o *Options) {
+		o.Window = window
+		o.Buckets = buckets
+	}
+}
+
+func WithMinRequests(n int) Option {
+	return func(o *Options) {
+		o.MinRequests = n
+	}
+}
+
+func WithErrorRate(rate float64) Option {
+	return func(o *Options) {
+		o.ErrorRate = rate
+	}
+}
+
+// WithSlowCall 耗时超过 duration 的请求比例达到 rate 时熔断
+func WithSlowCall(duration time.Duration, rate float64) Option {
+	return func(o *Options) {
+		o.SlowCallDuration = duration
+		o.SlowCallRate = rate
+	}
+}
+
+func WithOpenTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.OpenTimeout = timeout
+	}
+}
+
+func WithHalfOpenMaxRequests(n int) Option {
+	return func(o *Options) {
+		o.HalfOpenMaxRequests = n
+	}
+}
+
+func WithIsFailure(fn func(err error) bool) Option {
+	return func(o *Options) {
+		o.IsFailure = fn
+	}
+}
+
+func WithIsIgnored(fn func(err error) bool) Option {
+	return func(o *Options) {
+		o.IsIgnored = fn
+	}
+}
+
+func WithOnStateChange(fn func(name string, from, to State)) Option {
+	return func(o *Options) {
+		o.OnStateChange = fn
+	}
+}
+
+// WithLogger 状态变化时输出日志，默认不输出
+func WithLogger(logger logger.ILogger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// WithMeterProvider 默认使用全局 MeterProvider
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(o *Options) {
+		o.MeterProvider = provider
+	}
+}
+
+// Breaker 熔断器，按滚动窗口内的失败率与慢调用比例在关闭、打开、半开状态间切换
+//
+//	b := breaker.NewBreaker("volcengine.sms", breaker.WithLogger(log))
+//	err := b.Execute(ctx, func(ctx context.Context) error {
+//		return client.SendSmsWithContext(ctx, ...)
+//	})
+type Breaker struct {
+	name string
+	opts Options
+
+	mu         sync.Mutex
+	state      State
+	generation uint64
+	openedAt   time.Time
+	halfOpen   int // 半开状态已放行的探测请求数
+	halfOpenOK int // 半开状态已成功的探测请求数
+	window     *window
+	now        func() time.Time
+
+	stateChanges metric.Int64Counter
+	rejected     metric.Int64Counter
+}
+
+// NewBreaker 创建熔断器，name 用于日志与指标
+func NewBreaker(name string, options ...Option) *Breaker {
+	opts := Options{
+		Window:              10 * time.Second,
+		Buckets:             10,
+		MinRequests:         20,
+		ErrorRate:           0.5,
+		SlowCallRate:        1,
+		OpenTimeout:         30 * time.Second,
+		HalfOpenMaxRequests: 1,
+		IsFailure:           defaultIsFailure,
+		IsIgnored:           defaultIsIgnored,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	// 非法配置恢复为默认值
+	if opts.Window <= 0 {
+		opts.Window = 10 * time.Second
+	}
+	if opts.MinRequests <= 0 {
+		opts.MinRequests = 20
+	}
+	if opts.ErrorRate <= 0 || opts.ErrorRate > 1 {
+		opts.ErrorRate = 0.5
+	}
+	if opts.SlowCallRate <= 0 || opts.SlowCallRate > 1 {
+		opts.SlowCallRate = 1
+	}
+	if opts.Buckets <= 0 {
+		opts.Buckets = 10
+	}
+	if opts.Window < time.Duration(opts.Buckets) {
+		opts.Buckets = 1
+	}
+	if opts.HalfOpenMaxRequests <= 0 {
+		opts.HalfOpenMaxRequests = 1
+	}
+	if opts.IsFailure == nil {
+		opts.IsFailure = defaultIsFailure
+	}
+	if opts.IsIgnored == nil {
+		opts.IsIgnored = defaultIsIgnored
+	}
+	if opts.MeterProvider == nil {
+		opts.MeterProvider = otel.GetMeterProvider()
+	}
+
+	b := &Breaker{
+		name:   name,
+		opts:   opts,
+		window: newWindow(opts.Window, opts.Buckets),
+		now:    time.Now,
+	}
+	b.initMeter(opts.MeterProvider.Meter("kiwi-lib/breaker"))
+	return b
+}
+
+func defaultIsFailure(err error) bool {
+	return err != nil
+}
+
+func defaultIsIgnored(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State 返回当前状态，打开状态超过 OpenTimeout 时返回半开
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh(context.Background())
+	return b.state
+}
+
+// Execute 熔断器允许时执行 fn，打开状态返回 ErrOpenState，半开状态探测请求数已满时返回 ErrTooManyRequests
+// fn panic 时记为失败后继续 panic
+func (b *Breaker) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	done, err := b.Allow(ctx)
+	if err != nil {
+		return err
+	}
+
+	outcome := OutcomeFailure
+	defer func() { done(outcome) }()
+
+	err = fn(ctx)
+	outcome = b.Outcome(err)
+	return err
+}
+
+// Outcome 按 IsIgnored 与 IsFailure 判断 err 对应的请求结果
+func (b *Breaker) Outcome(err error) Outcome {
+	switch {
+	case b.opts.IsIgnored(err):
+		return OutcomeIgnored
+	case b.opts.IsFailure(err):
+		return OutcomeFailure
+	}
+	return OutcomeSuccess
+}
+
+// Allow 判断是否允许请求通过，允许时调用方在请求结束后必须调用 done 上报结果
+// 耗时从 Allow 返回时开始计算
+func (b *Breaker) Allow(ctx context.Context) (done func(outcome Outcome), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(ctx)
+	switch b.state {
+	case StateOpen:
+		b.reject(ctx, StateOpen)
+		return nil, ErrOpenState
+	case StateHalfOpen:
+		if b.halfOpen >= b.opts.HalfOpenMaxRequests {
+			b.reject(ctx, StateHalfOpen)
+			return nil, ErrTooManyRequests
+		}
+		b.halfOpen++
+	}
+
+	generation := b.generation
+	start := b.now()
+	return func(outcome Outcome) {
+		b.done(ctx, generation, outcome, b.now().Sub(start))
+	}, nil
+}
+
+func (b *Breaker) done(ctx context.Context, generation uint64, outcome Outcome, elapsed time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(ctx)
+	// 状态已经变化，忽略之前状态下发出的请求结果
+	if generation != b.generation {
+		return
+	}
+
+	if outcome == OutcomeIgnored {
+		if b.state == StateHalfOpen {
+			b.halfOpen--
+		}
+		return
+	}
+
+	success := outcome == OutcomeSuccess
+	slow := b.opts.SlowCallDuration > 0 && elapsed >= b.opts.SlowCallDuration
+	switch b.state {
+	case StateClosed:
+		b.window.add(b.now(), success, slow)
+		if b.shouldTrip() {
+			b.setState(ctx, StateOpen)
+		}
+	case StateHalfOpen:
+		if !success || slow {
+			b.setState(ctx, StateOpen)
+			return
+		}
+		b.halfOpenOK++
+		if b.halfOpenOK >= b.opts.HalfOpenMaxRequests {
+			b.setState(ctx, StateClosed)
+		}
+	}
+}
+
+func (b *Breaker) shouldTrip() bool {
+	total, failures, slow := b.window.counts(b.now())
+	if total == 0 || total < b.opts.MinRequests {
+		return false
+	}
+	if float64(failures)/float64(total) >= b.opts.ErrorRate {
+		return true
+	}
+	return b.opts.SlowCallDuration > 0 && float64(slow)/float64(total) >= b.opts.SlowCallRate
+}
+
+// refresh 打开状态超过 OpenTimeout 后进入半开状态
+func (b *Breaker) refresh(ctx context.Context) {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.opts.OpenTimeout {
+		b.setState(ctx, StateHalfOpen)
+	}
+}
+
+func (b *Breaker) setState(ctx context.Context, state State) {
+	if b.state == state {
+		return
+	}
+	from := b.state
+	b.state = state
+	b.generation++
+	b.halfOpen = 0
+	b.halfOpenOK = 0
+
+	switch state {
+	case StateOpen:
+		b.openedAt = b.now()
+	case StateClosed:
+		b.window.reset()
+	}
+
+	b.stateChanges.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("breaker.name", b.name),
+		attribute.String("breaker.from", from.String()),
+		attribute.String("breaker.to", state.String()),
+	))
+	if b.opts.Logger != nil {
+		if state == StateOpen {
+			b.opts.Logger.Warnf(ctx, "circuit breaker %s: %s -> %s", b.name, from, state)
+		} else {
+			b.opts.Logger.Infof(ctx, "circuit breaker %s: %s -> %s", b.name, from, state)
+		}
+	}
+	if b.opts.OnStateChange != nil {
+		b.opts.OnStateChange(b.name, from, state)
+	}
+}
+
+func (b *Breaker) reject(ctx context.Context, state State) {
+	b.rejected.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("breaker.name", b.name),
+		attribute.String("breaker.state", state.String()),
+	))
+}
+
+// initMeter 创建失败时通过 otel.Handle 上报错误并使用 noop 指标
+func (b *Breaker) initMeter(meter metric.Meter) {
+	fallback := noop.Meter{}
+
+	var err error
+	b.stateChanges, err = meter.Int64Counter(
+		"breaker.state_changes",
+		metric.WithDescription("Number of circuit breaker state transitions."),
+	)
+	if err != nil {
+		otel.Handle(err)
+		b.stateChanges, _ = fallback.Int64Counter("")
+	}
+
+	b.rejected, err = meter.Int64Counter(
+		"breaker.rejected_requests",
+		metric.WithDescription("Number of requests rejected by the circuit breaker."),
+	)
+	if err != nil {
+		otel.Handle(err)
+		b.rejected, _ = fallback.Int64Counter("")
+	}
+
+	_, err = meter.Int64ObservableGauge(
+		"breaker.state",
+		metric.WithDescription("Current circuit breaker state: 0 closed, 1 half-open, 2 open."),
+		metric.WithInt64Callback(func(ctx context.Context, o metric.Int64Observer) error {
+			o.Observe(int64(b.State()), metric.WithAttributes(attribute.String("breaker.name", b.name)))
+			return nil
+		}),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+}
+
+// window 按时间分桶的滚动窗口
+type window struct {
+	size    time.Duration
+	buckets []bucket
+}
+
+type bucket struct {
+	start    time.Time
+	total    int
+	failures int
+	slow     int
+}
+
+func newWindow(size time.Duration, n int) *window {
+	return &window{
+		size:    size / time.Duration(n),
+		buckets: make([]bucket, n),
+	}
+}
+
+func (w *window) add(now time.Time, success, slow bool) {
+	start := now.Truncate(w.size)
+	b := &w.buckets[int(start.UnixNano()/int64(w.size))%len(w.buckets)]
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+	b.total++
+	if !success {
+		b.failures++
+	}
+	if slow {
+		b.slow++
+	}
+}
+
+func (w *window) counts(now time.Time) (total, failures, slow int) {
+	oldest := now.Truncate(w.size).Add(-w.size * time.Duration(len(w.buckets)-1))
+	for _, b := range w.buckets {
+		if b.start.Before(oldest) {
+			continue
+		}
+		total += b.total
+		failures += b.failures
+		slow += b.slow
+	}
+	return
+}
+
+func (w *window) reset() {
+	clear(w.buckets)
+}
@@ -0,0 +1,222 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var errFail = errors.New("fail")
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBreaker(options ...Option) (*Breaker, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	b := NewBreaker("test", options...)
+	b.now = clock.now
+	return b, clock
+}
+
+func TestBreakerTripsOnErrorRate(t *testing.T) {
+	var changes []State
+	b, clock := newTestBreaker(
+		WithMinRequests(4),
+		WithErrorRate(0.5),
+		WithOpenTimeout(time.Second),
+		WithOnStateChange(func(name string, from, to State) { changes = append(changes, to) }),
+	)
+	ctx := context.Background()
+
+	for _, err := range []error{nil, errFail, nil} {
+		_ = b.Execute(ctx, func(context.Context) error { return err })
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s before MinRequests, want closed", b.State())
+	}
+	_ = b.Execute(ctx, func(context.Context) error { return errFail })
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+
+	called := false
+	if err := b.Execute(ctx, func(context.Context) error { called = true; return nil }); !errors.Is(err, ErrOpenState) || called {
+		t.Fatalf("open breaker err = %v, called = %v", err, called)
+	}
+
+	clock.advance(time.Second)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state = %s after OpenTimeout, want half-open", b.State())
+	}
+	done, err := b.Allow(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Allow(ctx); !errors.Is(err, ErrTooManyRequests) {
+		t.Fatalf("second probe err = %v, want ErrTooManyRequests", err)
+	}
+	done(OutcomeSuccess)
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s after successful probe, want closed", b.State())
+	}
+
+	want := []State{StateOpen, StateHalfOpen, StateClosed}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("changes = %v, want %v", changes, want)
+		}
+	}
+}
+
+func TestBreakerHalfOpenFailureReopens(t *testing.T) {
+	b, clock := newTestBreaker(WithMinRequests(1), WithOpenTimeout(time.Second))
+	ctx := context.Background()
+
+	_ = b.Execute(ctx, func(context.Context) error { return errFail })
+	clock.advance(time.Second)
+	_ = b.Execute(ctx, func(context.Context) error { return errFail })
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+}
+
+func TestBreakerSlowCalls(t *testing.T) {
+	b, clock := newTestBreaker(WithMinRequests(2), WithSlowCall(time.Second, 0.5))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_ = b.Execute(ctx, func(context.Context) error {
+			clock.advance(2 * time.Second)
+			return nil
+		})
+	}
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+}
+
+func TestBreakerWindowExpires(t *testing.T) {
+	b, clock := newTestBreaker(WithMinRequests(2), WithWindow(10*time.Second, 10))
+	ctx := context.Background()
+
+	_ = b.Execute(ctx, func(context.Context) error { return errFail })
+	clock.advance(11 * time.Second)
+	_ = b.Execute(ctx, func(context.Context) error { return errFail })
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s, failures outside window should not count", b.State())
+	}
+}
+
+func TestBreakerIgnoresCanceled(t *testing.T) {
+	b, _ := newTestBreaker(WithMinRequests(1))
+	_ = b.Execute(context.Background(), func(context.Context) error { return context.Canceled })
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s, context.Canceled should not count as failure", b.State())
+	}
+}
+
+func TestBreakerCanceledReleasesProbe(t *testing.T) {
+	b, clock := newTestBreaker(WithMinRequests(1), WithOpenTimeout(time.Second))
+	ctx := context.Background()
+	_ = b.Execute(ctx, func(context.Context) error { return errFail })
+	clock.advance(time.Second)
+
+	_ = b.Execute(ctx, func(context.Context) error { return context.Canceled })
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state = %s after canceled probe, want half-open", b.State())
+	}
+	if err := b.Execute(ctx, func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("probe after canceled probe err = %v", err)
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s, want closed", b.State())
+	}
+}
+
+func TestBreakerPanicCountsAsFailure(t *testing.T) {
+	b, clock := newTestBreaker(WithMinRequests(1), WithOpenTimeout(time.Second))
+	execPanic := func() (recovered any) {
+		defer func() { recovered = recover() }()
+		_ = b.Execute(context.Background(), func(context.Context) error { panic("boom") })
+		return nil
+	}
+
+	if execPanic() != "boom" {
+		t.Fatal("panic should propagate")
+	}
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, panic should count as failure", b.State())
+	}
+	clock.advance(time.Second)
+	_ = execPanic()
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, panicking probe should reopen", b.State())
+	}
+}
+
+func TestTransport(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	b, _ := newTestBreaker(WithMinRequests(2))
+	client := &http.Client{Transport: NewTransport(nil, b)}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if _, err := client.Get(srv.URL); !errors.Is(err, ErrOpenState) {
+		t.Fatalf("err = %v, want ErrOpenState", err)
+	}
+	if requests != 2 {
+		t.Fatalf("server got %d requests, want 2", requests)
+	}
+}
+
+func TestBreakerInvalidOptions(t *testing.T) {
+	b, clock := newTestBreaker(
+		WithWindow(0, 10),
+		WithHalfOpenMaxRequests(0),
+		WithIsFailure(nil),
+		WithMinRequests(1),
+		WithOpenTimeout(time.Second),
+	)
+	ctx := context.Background()
+
+	_ = b.Execute(ctx, func(context.Context) error { return errFail })
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+	clock.advance(time.Second)
+	if err := b.Execute(ctx, func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("half-open probe err = %v", err)
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s, want closed", b.State())
+	}
+
+	for _, rate := range []float64{0, -1, 1.5} {
+		rb, _ := newTestBreaker(WithMinRequests(-1), WithErrorRate(rate), WithSlowCall(time.Millisecond, rate))
+		if rb.opts.MinRequests != 20 || rb.opts.ErrorRate != 0.5 || rb.opts.SlowCallRate != 1 {
+			t.Fatalf("rate %v: opts = %+v, want defaults", rate, rb.opts)
+		}
+	}
+
+	tiny, _ := newTestBreaker(WithWindow(time.Nanosecond, 10))
+	_ = tiny.Execute(ctx, func(context.Context) error { return nil })
+}
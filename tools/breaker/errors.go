@@ -0,0 +1,10 @@
+package breaker
+
+import "errors"
+
+var (
+	// ErrOpenState 熔断器处于打开状态，请求被直接拒绝
+	ErrOpenState = errors.New("circuit breaker is open")
+	// ErrTooManyRequests 半开状态下探测请求数已达上限
+	ErrTooManyRequests = errors.New("circuit breaker: too many requests in half-open state")
+)
@@ -0,0 +1,57 @@
+package breaker
+
+import (
+	"net/http"
+)
+
+// Transport 经过熔断器发送请求，网络错误与 5xx 响应记为失败
+// 熔断时返回 ErrOpenState 或 ErrTooManyRequests，不发送请求
+type Transport struct {
+	Transport http.RoundTripper
+	Breaker   *Breaker
+	// IsFailure 判断响应是否记为失败，默认 err 不为 nil 或状态码 >= 500
+	IsFailure func(resp *http.Response, err error) bool
+}
+
+func NewTransport(base http.RoundTripper, breaker *Breaker) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{
+		Transport: base,
+		Breaker:   breaker,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	done, err := t.Breaker.Allow(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.Transport.RoundTrip(req)
+	done(t.outcome(resp, err))
+	return resp, err
+}
+
+// outcome 被 Breaker 的 IsIgnored 忽略的错误不计入统计，其余按 IsFailure 判断
+func (t *Transport) outcome(resp *http.Response, err error) Outcome {
+	if err != nil && t.Breaker.opts.IsIgnored(err) {
+		return OutcomeIgnored
+	}
+	isFailure := t.IsFailure
+	if isFailure == nil {
+		isFailure = t.isFailure
+	}
+	if isFailure(resp, err) {
+		return OutcomeFailure
+	}
+	return OutcomeSuccess
+}
+
+func (t *Transport) isFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return t.Breaker.opts.IsFailure(err)
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
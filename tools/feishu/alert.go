@@ -32,18 +32,25 @@ func SendFeishuAlertAsync(ctx *gin.Context, logger logger.ILogger, webhookURL, t
 		}
 
 		body, _ := template.BuildAlertCard(title, fullRequestURL, logObj)
-		resp, err2 := http.Post(webhookURL, "application/json", bytes.NewReader(body))
-		if err2 != nil {
-			logger.Errorf(ctx, "SendFeishuAlertAsync Error %+v", err2)
-			return
-		}
-		defer resp.Body.Close()
-		if resp.StatusCode != http.StatusOK {
-			logger.Errorf(ctx, "SendFeishuAlertAsync Error %d", resp.StatusCode)
+		if err := postCard(http.DefaultClient, webhookURL, body); err != nil {
+			logger.Errorf(ctx, "SendFeishuAlertAsync Error %+v", err)
 		}
 	})
 }
 
+// postCard 向飞书机器人 webhook 投递卡片消息
+func postCard(client *http.Client, webhookURL string, body []byte) error {
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("feishu webhook status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
 func getNamespace(ctx *gin.Context) string {
 	if v, ok := ctx.Get("namespace"); ok {
 		if s, ok := v.(string); ok {
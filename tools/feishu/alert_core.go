@@ -0,0 +1,301 @@
+package feishu
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/logger"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/feishu/template"
+	"go.uber.org/zap/zapcore"
+)
+
+// AlertCoreConfig 日志告警配置，一个 AlertCore 对应一个飞书群（channel）
+type AlertCoreConfig struct {
+	// WebhookURL 飞书机器人 webhook
+	WebhookURL string
+	// ServiceName 服务名，展示在卡片中
+	ServiceName string
+	// Title 卡片标题，默认为 "<ServiceName> 错误告警"
+	Title string
+	// Level 触发告警的日志级别，默认 Error 及以上；携带 alert=true 的 Info 及以上日志总是告警
+	Level zapcore.LevelEnabler
+	// AggregateWindow 相同错误的聚合窗口，窗口内只发送第一条，窗口结束时发送重复次数汇总
+	AggregateWindow time.Duration
+	// RateLimit 每个 RateWindow 内该 channel 最多发送的卡片数
+	RateLimit  int
+	RateWindow time.Duration
+	// Silences 静默列表，消息包含其中任一子串时不告警
+	Silences []string
+	// MaxConcurrency 同时发送中的卡片数上限，默认 4，已满时丢弃新的卡片并通过 OnError 通知
+	MaxConcurrency int
+	HTTPClient     *http.Client
+	// OnError 发送失败时回调，不要在回调中以 Error 级别写日志，以免告警循环
+	OnError func(error)
+}
+
+// ErrAlertDropped 发送中的卡片数达到 MaxConcurrency，卡片被丢弃
+var ErrAlertDropped = errors.New("feishu alert dropped: too many in-flight cards")
+
+// AlertCore 将错误日志发送到飞书的 zapcore.Core，通过 logger.WithCores 挂载
+type AlertCore struct {
+	config AlertCoreConfig
+	fields []zapcore.Field
+	state  *alertState
+
+	// 以下字段仅在单条日志的 Check 之后设置
+	group   string
+	tagOnly bool
+}
+
+type alertGroup struct {
+	count  int
+	logObj map[string]interface{}
+}
+
+type alertState struct {
+	mu          sync.Mutex
+	groups      map[string]*alertGroup
+	silences    map[string]time.Time // pattern -> 过期时间，零值表示永久
+	windowStart time.Time
+	sent        int
+	rateLimited int
+	// inflight 限制同时发送的卡片数，webhook 变慢时避免 goroutine 堆积
+	inflight chan struct{}
+}
+
+func NewAlertCore(config AlertCoreConfig) *AlertCore {
+	if config.Title == "" {
+		config.Title = config.ServiceName + " 错误告警"
+	}
+	if config.Level == nil {
+		config.Level = zapcore.ErrorLevel
+	}
+	if config.AggregateWindow <= 0 {
+		config.AggregateWindow = time.Minute
+	}
+	if config.RateLimit <= 0 {
+		config.RateLimit = 10
+	}
+	if config.RateWindow <= 0 {
+		config.RateWindow = time.Minute
+	}
+	if config.MaxConcurrency <= 0 {
+		config.MaxConcurrency = 4
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	state := &alertState{
+		groups:   make(map[string]*alertGroup),
+		silences: make(map[string]time.Time),
+		inflight: make(chan struct{}, config.MaxConcurrency),
+	}
+	for _, pattern := range config.Silences {
+		state.silences[pattern] = time.Time{}
+	}
+
+	return &AlertCore{
+		config: config,
+		state:  state,
+	}
+}
+
+// Silence 在 d 时间内静默包含 pattern 的告警，d <= 0 表示永久静默
+func (c *AlertCore) Silence(pattern string, d time.Duration) {
+	var expire time.Time
+	if d > 0 {
+		expire = time.Now().Add(d)
+	}
+
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	c.state.silences[pattern] = expire
+}
+
+// Unsilence 取消对 pattern 的静默
+func (c *AlertCore) Unsilence(pattern string) {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	delete(c.state.silences, pattern)
+}
+
+func (c *AlertCore) Enabled(level zapcore.Level) bool {
+	return level >= zapcore.InfoLevel
+}
+
+func (c *AlertCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(append([]zapcore.Field{}, c.fields...), fields...)
+	return &clone
+}
+
+func (c *AlertCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+
+	// 此时 Message 仍是未格式化的模板，以此分组可以聚合同一调用点的错误
+	child := *c
+	child.group = ent.Level.String() + "|" + ent.LoggerName + "|" + ent.Message
+	child.tagOnly = !c.config.Level.Enabled(ent.Level)
+	return ce.AddCore(ent, &child)
+}
+
+func (c *AlertCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	all := append(append([]zapcore.Field{}, c.fields...), fields...)
+	if c.tagOnly && !hasAlertTag(all) {
+		return nil
+	}
+
+	now := time.Now()
+	logObj := c.buildLogObj(ent, all)
+
+	c.state.mu.Lock()
+	if c.silencedLocked(ent.Message, now) {
+		c.state.mu.Unlock()
+		return nil
+	}
+
+	if g, ok := c.state.groups[c.group]; ok {
+		g.count++
+		g.logObj = logObj
+		c.state.mu.Unlock()
+		return nil
+	}
+
+	c.state.groups[c.group] = &alertGroup{}
+	group := c.group
+	time.AfterFunc(c.config.AggregateWindow, func() {
+		c.flush(group)
+	})
+
+	allowed := c.allowLocked(now, logObj)
+	c.state.mu.Unlock()
+
+	if allowed {
+		c.send(c.config.Title, logObj)
+	}
+	return nil
+}
+
+func (c *AlertCore) Sync() error {
+	return nil
+}
+
+// flush 聚合窗口结束，发送窗口内重复错误的汇总
+func (c *AlertCore) flush(group string) {
+	c.state.mu.Lock()
+	g, ok := c.state.groups[group]
+	delete(c.state.groups, group)
+	if !ok || g.count == 0 {
+		c.state.mu.Unlock()
+		return
+	}
+
+	allowed := c.allowLocked(time.Now(), g.logObj)
+	c.state.mu.Unlock()
+
+	if allowed {
+		g.logObj["repeat_count"] = g.count
+		g.logObj["aggregate_window"] = c.config.AggregateWindow.String()
+		c.send(c.config.Title+" (重复)", g.logObj)
+	}
+}
+
+// allowLocked channel 维度的固定窗口限流，放行时在 logObj 中附带此前被限流的条数，调用方需持有锁
+func (c *AlertCore) allowLocked(now time.Time, logObj map[string]interface{}) bool {
+	if now.Sub(c.state.windowStart) >= c.config.RateWindow {
+		c.state.windowStart = now
+		c.state.sent = 0
+	}
+
+	if c.state.sent >= c.config.RateLimit {
+		c.state.rateLimited++
+		return false
+	}
+
+	c.state.sent++
+	if c.state.rateLimited > 0 {
+		logObj["rate_limited_count"] = c.state.rateLimited
+		c.state.rateLimited = 0
+	}
+	return true
+}
+
+// silencedLocked 调用方需持有锁
+func (c *AlertCore) silencedLocked(msg string, now time.Time) bool {
+	for pattern, expire := range c.state.silences {
+		if !expire.IsZero() && now.After(expire) {
+			delete(c.state.silences, pattern)
+			continue
+		}
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *AlertCore) buildLogObj(ent zapcore.Entry, fields []zapcore.Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	logObj := enc.Fields
+	logObj["ts"] = ent.Time.Format(time.DateTime)
+	logObj["level"] = ent.Level.String()
+	logObj["msg"] = ent.Message
+	logObj["service"] = c.config.ServiceName
+	if ent.Caller.Defined {
+		logObj["caller"] = ent.Caller.TrimmedPath()
+	}
+	if ent.Stack != "" {
+		logObj["stack"] = ent.Stack
+	}
+
+	return logObj
+}
+
+// send 异步发送卡片，不阻塞写日志，发送中的卡片数已满时直接丢弃
+func (c *AlertCore) send(title string, logObj map[string]interface{}) {
+	select {
+	case c.state.inflight <- struct{}{}:
+	default:
+		if c.config.OnError != nil {
+			c.config.OnError(ErrAlertDropped)
+		}
+		return
+	}
+
+	go func() {
+		defer func() { <-c.state.inflight }()
+		defer func() {
+			if r := recover(); r != nil && c.config.OnError != nil {
+				c.config.OnError(fmt.Errorf("feishu alert panic: %v", r))
+			}
+		}()
+
+		body, err := template.BuildLogAlertCard(title, c.config.ServiceName, logObj)
+		if err == nil {
+			err = postCard(c.config.HTTPClient, c.config.WebhookURL, body)
+		}
+		if err != nil && c.config.OnError != nil {
+			c.config.OnError(err)
+		}
+	}()
+}
+
+func hasAlertTag(fields []zapcore.Field) bool {
+	for _, f := range fields {
+		if f.Key == logger.FieldAlert && f.Type == zapcore.BoolType && f.Integer == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package feishu
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/logger"
+)
+
+type cardRecorder struct {
+	mu    sync.Mutex
+	cards []string
+}
+
+func (r *cardRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	r.cards = append(r.cards, string(body))
+	r.mu.Unlock()
+}
+
+func (r *cardRecorder) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.cards...)
+}
+
+func TestAlertCoreAggregateAndSilence(t *testing.T) {
+	recorder := &cardRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	core := NewAlertCore(AlertCoreConfig{
+		WebhookURL:      server.URL,
+		ServiceName:     "kiwi-test",
+		AggregateWindow: 100 * time.Millisecond,
+		Silences:        []string{"redis timeout"},
+	})
+
+	log, err := logger.NewLogger(logger.WithLevel("error"), logger.WithCores(core))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		log.Errorf(ctx, "query user %d failed", i)
+	}
+	log.Errorf(ctx, "redis timeout on %s", "cache")
+	log.Infof(ctx, "not an alert")
+	log.Infof(logger.WithAlert(ctx), "tagged %s", "alert")
+
+	time.Sleep(300 * time.Millisecond)
+
+	cards := recorder.snapshot()
+	if len(cards) != 3 {
+		t.Fatalf("expected 3 cards (first error, tagged info, repeat summary), got %d: %v", len(cards), cards)
+	}
+
+	var repeat, tagged bool
+	for _, card := range cards {
+		if strings.Contains(card, "redis timeout") {
+			t.Fatalf("silenced error was sent: %s", card)
+		}
+		if strings.Contains(card, `\"repeat_count\": 4`) {
+			repeat = true
+		}
+		if strings.Contains(card, "tagged alert") {
+			tagged = true
+		}
+	}
+	if !repeat || !tagged {
+		t.Fatalf("missing repeat summary or tagged alert: %v", cards)
+	}
+}
+
+func TestAlertCoreRateLimit(t *testing.T) {
+	recorder := &cardRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	core := NewAlertCore(AlertCoreConfig{
+		WebhookURL: server.URL,
+		RateLimit:  2,
+		RateWindow: time.Minute,
+	})
+
+	log, err := logger.NewLogger(logger.WithLevel("error"), logger.WithCores(core))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log.Errorf(context.Background(), "a")
+	log.Errorf(context.Background(), "b")
+	log.Errorf(context.Background(), "c")
+
+	time.Sleep(100 * time.Millisecond)
+
+	if cards := recorder.snapshot(); len(cards) != 2 {
+		t.Fatalf("expected 2 cards, got %d", len(cards))
+	}
+}
+
+func TestAlertCoreMaxConcurrency(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	var mu sync.Mutex
+	var dropped int
+	core := NewAlertCore(AlertCoreConfig{
+		WebhookURL:     server.URL,
+		MaxConcurrency: 1,
+		OnError: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if err == ErrAlertDropped {
+				dropped++
+			}
+		},
+	})
+
+	log, err := logger.NewLogger(logger.WithLevel("error"), logger.WithCores(core))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log.Errorf(context.Background(), "a")
+	log.Errorf(context.Background(), "b")
+	log.Errorf(context.Background(), "c")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if dropped != 2 {
+		t.Fatalf("expected 2 dropped cards, got %d", dropped)
+	}
+}
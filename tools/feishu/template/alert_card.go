@@ -27,6 +27,15 @@ type FeishuCardTitle struct {
 
 // BuildAlertCard 组装飞书交互式卡片消息体
 func BuildAlertCard(title, fullRequestURL string, logObj map[string]interface{}) ([]byte, error) {
+	return buildCard(title, "API路径", fullRequestURL, logObj)
+}
+
+// BuildLogAlertCard 组装日志告警的飞书卡片消息体，source 一般为服务名
+func BuildLogAlertCard(title, source string, logObj map[string]interface{}) ([]byte, error) {
+	return buildCard(title, "来源", source, logObj)
+}
+
+func buildCard(title, label, value string, logObj map[string]interface{}) ([]byte, error) {
 	logStr, _ := json.MarshalIndent(logObj, "", "  ")
 	card := FeishuInteractiveCard{
 		Header: FeishuCardHeader{
@@ -41,7 +50,7 @@ func BuildAlertCard(title, fullRequestURL string, logObj map[string]interface{})
 				"tag": "div",
 				"text": map[string]interface{}{
 					"tag":     "lark_md",
-					"content": fmt.Sprintf("**API路径:** %s", fullRequestURL),
+					"content": fmt.Sprintf("**%s:** %s", label, value),
 				},
 			},
 			map[string]interface{}{"tag": "hr"},
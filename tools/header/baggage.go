@@ -0,0 +1,148 @@
+package header
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BaggageHeaders 需要以 W3C baggage 传递的业务头部及其 baggage key，默认为空
+// baggage 会传给所有下游（包括第三方服务），不要加入 Authorization 等敏感头部；需在启动时设置
+//
+//	header.BaggageHeaders = map[string]string{
+//		header.HeaderXUserID:   "user.id",
+//		header.HeaderXTenantID: "tenant.id",
+//	}
+var BaggageHeaders = map[string]string{}
+
+var traceBaggage = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// credentialHeaders 凭证类头部，Propagator 与 InjectMap 不会写出
+var credentialHeaders = []string{HeaderAuthorization, "Proxy-Authorization", "Cookie", HeaderUserAgent}
+
+// Propagator 统一 trace context、baggage 与业务头部的 propagator，不写出 Authorization 等凭证头部
+// 可通过 otel.SetTextMapPropagator 或 otelutils.Config.Propagator 设为全局，
+// 设为全局后 gRPC、消息队列等插件会向所有下游传递业务头部；HTTP 出站请求应使用 xhttp 的 Policy 控制
+type Propagator struct{}
+
+var _ propagation.TextMapPropagator = Propagator{}
+
+func (Propagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	inject(ctx, carrier, false)
+}
+
+func (Propagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return extract(ctx, carrier, make(map[string]string))
+}
+
+func (Propagator) Fields() []string {
+	fields := traceBaggage.Fields()
+	for _, key := range PropagatedHeaders {
+		if !isCredential(key) {
+			fields = append(fields, key)
+		}
+	}
+	return fields
+}
+
+// InjectMap 将 trace context、baggage 与业务头部写入 map，用于 Redis 队列等消息的元数据，不包含凭证头部
+func InjectMap(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	inject(ctx, carrier, false)
+	return carrier
+}
+
+// ExtractMap 从 InjectMap 生成的 map 中恢复 context，消费消息时使用
+func ExtractMap(ctx context.Context, m map[string]string) context.Context {
+	return extract(ctx, propagation.MapCarrier(m), make(map[string]string))
+}
+
+// UserID 返回透传的 X-User-Id，头部缺失时读取 baggage
+func UserID(ctx context.Context) string {
+	return lookup(ctx, HeaderXUserID)
+}
+
+// TenantID 返回透传的 X-Tenant-Id，头部缺失时读取 baggage
+func TenantID(ctx context.Context) string {
+	return lookup(ctx, HeaderXTenantID)
+}
+
+// OrgID 返回透传的 X-Org-Id，头部缺失时读取 baggage
+func OrgID(ctx context.Context) string {
+	return lookup(ctx, HeaderXOrgID)
+}
+
+// RequestID 返回透传的 X-Request-Id，头部缺失时读取 baggage
+func RequestID(ctx context.Context) string {
+	return lookup(ctx, HeaderXRequestID)
+}
+
+func lookup(ctx context.Context, key string) string {
+	if v := GetPropagatedHeader(ctx, key); v != "" {
+		return v
+	}
+	if member, ok := BaggageHeaders[key]; ok {
+		return baggage.FromContext(ctx).Member(member).Value()
+	}
+	return ""
+}
+
+// inject credentials 为 false 时跳过 credentialHeaders
+func inject(ctx context.Context, carrier propagation.TextMapCarrier, credentials bool) {
+	if headers, ok := ctx.Value(contextKeyHeaders{}).(map[string]string); ok {
+		for key, value := range headers {
+			if !credentials && isCredential(key) {
+				continue
+			}
+			carrier.Set(key, value)
+		}
+	}
+
+	// 以当前 span 为准覆盖 context 中保存的 traceparent
+	traceBaggage.Inject(ctx, carrier)
+}
+
+func isCredential(key string) bool {
+	for _, h := range credentialHeaders {
+		if strings.EqualFold(h, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// extract 将业务头部写入 headers 并存入 context，BaggageHeaders 中的头部与 baggage 相互补全
+func extract(ctx context.Context, carrier propagation.TextMapCarrier, headers map[string]string) context.Context {
+	// 已有 span（如 otelgin 已提取）时不覆盖
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = propagation.TraceContext{}.Extract(ctx, carrier)
+	}
+
+	bag := baggage.FromContext(ctx)
+	incoming := baggage.FromContext(propagation.Baggage{}.Extract(context.Background(), carrier))
+	for _, m := range incoming.Members() {
+		bag, _ = bag.SetMember(m)
+	}
+
+	for _, key := range PropagatedHeaders {
+		if val := carrier.Get(key); val != "" {
+			headers[key] = val
+		}
+	}
+
+	for key, member := range BaggageHeaders {
+		if val, ok := headers[key]; ok {
+			if m, err := baggage.NewMemberRaw(member, val); err == nil {
+				bag, _ = bag.SetMember(m)
+			}
+		} else if val := bag.Member(member).Value(); val != "" {
+			headers[key] = val
+		}
+	}
+
+	ctx = baggage.ContextWithBaggage(ctx, bag)
+	return context.WithValue(ctx, contextKeyHeaders{}, headers)
+}
@@ -0,0 +1,147 @@
+package header
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Rule 按目标 host 匹配的头部传递规则
+type Rule struct {
+	// Hosts 匹配的 host，支持精确匹配、"*.example.com" 与 "*"
+	Hosts []string
+	// Internal 为 true 时匹配内网地址：回环与私有 IP、localhost、无点的主机名以及 .svc/.cluster.local/.internal 后缀
+	Internal bool
+
+	// Allow 允许传递的业务头部，为空时不传递任何业务头部
+	Allow []string
+	// Deny 禁止传递的头部，优先于 Allow
+	Deny []string
+	// Rename 传递时重命名头部，如 {"X-User-Id": "X-Upstream-User-Id"}
+	Rename map[string]string
+	// Baggage 是否传递 W3C baggage，baggage 中可能包含用户 id 等信息，外部服务不建议开启
+	Baggage bool
+}
+
+// Policy 出站请求的头部传递策略，按顺序匹配 Rules，都不匹配时使用 Default
+// traceparent 总是传递
+type Policy struct {
+	Rules   []Rule
+	Default Rule
+}
+
+// DefaultPolicy 内网服务传递除 Authorization 外的 PropagatedHeaders 与 baggage，外部服务只传递 X-Request-Id
+// 需要向内网服务传递 Authorization 时自行添加规则
+func DefaultPolicy() *Policy {
+	var internal []string
+	for _, key := range PropagatedHeaders {
+		if key != HeaderAuthorization {
+			internal = append(internal, key)
+		}
+	}
+
+	return &Policy{
+		Rules: []Rule{
+			{
+				Internal: true,
+				Allow:    internal,
+				Baggage:  true,
+			},
+		},
+		Default: Rule{
+			Allow: []string{HeaderXRequestID},
+		},
+	}
+}
+
+// Apply 按目标 host 的规则将 context 中透传的头部写入 header，只补充 header 中没有的头部，不覆盖调用方设置的值
+func (p *Policy) Apply(ctx context.Context, host string, header http.Header) {
+	rule := p.match(host)
+
+	if headers, ok := ctx.Value(contextKeyHeaders{}).(map[string]string); ok {
+		for key, value := range headers {
+			key = http.CanonicalHeaderKey(key)
+			if !rule.allows(key) {
+				continue
+			}
+			if to, ok := rule.rename(key); ok {
+				key = to
+			}
+			if header.Get(key) == "" {
+				header.Set(key, value)
+			}
+		}
+	}
+
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(header))
+	if rule.Baggage {
+		propagation.Baggage{}.Inject(ctx, propagation.HeaderCarrier(header))
+	}
+}
+
+func (p *Policy) match(host string) *Rule {
+	host = strings.ToLower(host)
+	for i := range p.Rules {
+		if p.Rules[i].matches(host) {
+			return &p.Rules[i]
+		}
+	}
+	return &p.Default
+}
+
+func (r *Rule) matches(host string) bool {
+	if r.Internal && isInternalHost(host) {
+		return true
+	}
+	for _, pattern := range r.Hosts {
+		pattern = strings.ToLower(pattern)
+		switch {
+		case pattern == "*" || pattern == host:
+			return true
+		case strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:]):
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) allows(key string) bool {
+	for _, deny := range r.Deny {
+		if strings.EqualFold(deny, key) {
+			return false
+		}
+	}
+	for _, allow := range r.Allow {
+		if strings.EqualFold(allow, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) rename(key string) (string, bool) {
+	for from, to := range r.Rename {
+		if strings.EqualFold(from, key) {
+			return to, true
+		}
+	}
+	return "", false
+}
+
+func isInternalHost(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast()
+	}
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return true
+	}
+	for _, suffix := range []string{".svc", ".svc.cluster.local", ".cluster.local", ".internal", ".local"} {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
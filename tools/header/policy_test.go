@@ -0,0 +1,72 @@
+package header
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderAuthorization, "Bearer secret")
+	req.Header.Set(HeaderXRequestID, "r-1")
+	req.Header.Set(HeaderXUserID, "u-1")
+	ctx := ExtractHeadersToContext(context.Background(), req)
+
+	policy := DefaultPolicy()
+	policy.Rules = append(policy.Rules, Rule{
+		Hosts:  []string{"*.partner.com"},
+		Allow:  []string{HeaderXRequestID, HeaderXUserID},
+		Rename: map[string]string{HeaderXUserID: "X-Partner-User"},
+	})
+
+	cases := []struct {
+		host string
+		want map[string]string
+	}{
+		{"user-service.default.svc.cluster.local", map[string]string{HeaderAuthorization: "", HeaderXUserID: "u-1", HeaderXRequestID: "r-1"}},
+		{"10.0.3.7", map[string]string{HeaderAuthorization: "", HeaderXUserID: "u-1"}},
+		{"api.partner.com", map[string]string{"X-Partner-User": "u-1", HeaderXUserID: "", HeaderAuthorization: ""}},
+		{"api.openai.com", map[string]string{HeaderXRequestID: "r-1", HeaderAuthorization: "", HeaderXUserID: ""}},
+	}
+
+	for _, tc := range cases {
+		out := http.Header{}
+		policy.Apply(ctx, tc.host, out)
+		for key, want := range tc.want {
+			if got := out.Get(key); got != want {
+				t.Errorf("%s: %s = %q, want %q", tc.host, key, got, want)
+			}
+		}
+	}
+}
+
+func TestPolicyDeny(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderAuthorization, "Bearer secret")
+	req.Header.Set(HeaderXTenantID, "t-1")
+	ctx := ExtractHeadersToContext(context.Background(), req)
+
+	policy := &Policy{Default: Rule{Allow: PropagatedHeaders, Deny: []string{"authorization"}}}
+	out := http.Header{}
+	policy.Apply(ctx, "example.com", out)
+	if out.Get(HeaderAuthorization) != "" || out.Get(HeaderXTenantID) != "t-1" {
+		t.Fatalf("unexpected headers: %v", out)
+	}
+}
+
+func TestPolicyKeepsExistingHeaders(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderAuthorization, "Bearer end-user")
+	req.Header.Set(HeaderXRequestID, "r-1")
+	ctx := ExtractHeadersToContext(context.Background(), req)
+
+	policy := &Policy{Default: Rule{Allow: PropagatedHeaders}}
+	out := http.Header{}
+	out.Set(HeaderAuthorization, "Bearer app-key")
+	policy.Apply(ctx, "localhost", out)
+	if out.Get(HeaderAuthorization) != "Bearer app-key" || out.Get(HeaderXRequestID) != "r-1" {
+		t.Fatalf("unexpected headers: %v", out)
+	}
+}
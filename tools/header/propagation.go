@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 // 业务相关需要透传的 HTTP 头部常量
@@ -53,6 +54,8 @@ var PropagatedHeaders = []string{
 type contextKeyHeaders struct{}
 
 // ExtractHeadersToContext 从HTTP请求中提取头部并存入context
+// 同时提取 W3C baggage，BaggageHeaders 中的头部会写入 baggage 并随 trace 继续向下游传递
+// websocket 握手请求同样适用
 func ExtractHeadersToContext(ctx context.Context, r *http.Request) context.Context {
 	headers := make(map[string]string)
 
@@ -63,24 +66,15 @@ func ExtractHeadersToContext(ctx context.Context, r *http.Request) context.Conte
 		}
 	}
 
-	// 2. 提取业务头部
-	for _, key := range PropagatedHeaders {
-		if val := r.Header.Get(key); val != "" {
-			headers[key] = val
-		}
-	}
-
-	return context.WithValue(ctx, contextKeyHeaders{}, headers)
+	return extract(ctx, propagation.HeaderCarrier(r.Header), headers)
 }
 
 // ApplyPropagatedHeaders 从 context 中获取透传的头部并应用到 http.Header
 // 直接修改传入的 header，无需调用者手动遍历
+//
+// Deprecated: 会将 Authorization 等全部头部传给任意目标，使用 Policy.Apply 或 xhttp.NewPropagationTransport
 func ApplyPropagatedHeaders(ctx context.Context, header http.Header) {
-	if headers, ok := ctx.Value(contextKeyHeaders{}).(map[string]string); ok {
-		for key, value := range headers {
-			header.Set(key, value)
-		}
-	}
+	inject(ctx, propagation.HeaderCarrier(header), true)
 }
 
 // GetPropagatedHeader 根据常量 key 获取在上下文中保存的对应头部值
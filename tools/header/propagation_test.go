@@ -0,0 +1,94 @@
+package header
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestBaggagePropagation(t *testing.T) {
+	BaggageHeaders = map[string]string{HeaderXUserID: "user.id", HeaderXTenantID: "tenant.id"}
+	defer func() { BaggageHeaders = map[string]string{} }()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderXUserID, "u-1")
+	req.Header.Set(HeaderXRequestID, "r-1")
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	req.Header.Set("baggage", "tenant.id=t-9")
+
+	ctx := ExtractHeadersToContext(context.Background(), req)
+	if UserID(ctx) != "u-1" || TenantID(ctx) != "t-9" || RequestID(ctx) != "r-1" {
+		t.Fatalf("unexpected getters: %q %q %q", UserID(ctx), TenantID(ctx), RequestID(ctx))
+	}
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		t.Fatal("trace context should be extracted")
+	}
+
+	// 经 Redis 消息传递后在消费端恢复
+	msg := InjectMap(ctx)
+	consumer := ExtractMap(context.Background(), msg)
+	if UserID(consumer) != "u-1" || TenantID(consumer) != "t-9" {
+		t.Fatalf("unexpected consumer getters: %q %q", UserID(consumer), TenantID(consumer))
+	}
+	if trace.SpanContextFromContext(consumer).TraceID() != trace.SpanContextFromContext(ctx).TraceID() {
+		t.Fatal("trace id should survive the queue")
+	}
+
+	// 只携带 baggage 的下游请求也能恢复业务头部
+	out := http.Header{}
+	propagation.Baggage{}.Inject(consumer, propagation.HeaderCarrier(out))
+	downstream := httptest.NewRequest(http.MethodGet, "/", nil)
+	downstream.Header.Set("baggage", out.Get("baggage"))
+	got := ExtractHeadersToContext(context.Background(), downstream)
+	if UserID(got) != "u-1" || GetPropagatedHeader(got, HeaderXUserID) != "u-1" {
+		t.Fatalf("baggage should restore X-User-Id, got %q", UserID(got))
+	}
+	if baggage.FromContext(got).Member("tenant.id").Value() != "t-9" {
+		t.Fatal("tenant baggage should be kept")
+	}
+}
+
+func TestApplyPropagatedHeaders(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderXTenantID, "t-1")
+	ctx := ExtractHeadersToContext(context.Background(), req)
+
+	out := http.Header{}
+	ApplyPropagatedHeaders(ctx, out)
+	if out.Get(HeaderXTenantID) != "t-1" || out.Get("baggage") != "" {
+		t.Fatalf("unexpected headers: %v", out)
+	}
+	if TenantID(ctx) != "t-1" {
+		t.Fatalf("unexpected tenant: %q", TenantID(ctx))
+	}
+}
+
+func TestPropagatorSkipsCredentials(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderAuthorization, "Bearer secret")
+	req.Header.Set("Cookie", "session=secret")
+	req.Header.Set(HeaderXRequestID, "r-1")
+	ctx := ExtractHeadersToContext(context.Background(), req)
+
+	out := http.Header{}
+	Propagator{}.Inject(ctx, propagation.HeaderCarrier(out))
+	msg := InjectMap(ctx)
+	for _, key := range []string{HeaderAuthorization, "Cookie"} {
+		if out.Get(key) != "" || msg[key] != "" {
+			t.Fatalf("%s should not be propagated: header %q, map %q", key, out.Get(key), msg[key])
+		}
+	}
+	if out.Get(HeaderXRequestID) != "r-1" || msg[HeaderXRequestID] != "r-1" {
+		t.Fatalf("X-Request-Id should be propagated: header %q, map %q", out.Get(HeaderXRequestID), msg[HeaderXRequestID])
+	}
+	for _, field := range (Propagator{}).Fields() {
+		if field == HeaderAuthorization {
+			t.Fatal("Fields should not include Authorization")
+		}
+	}
+}
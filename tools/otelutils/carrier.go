@@ -7,10 +7,12 @@ import (
 	"go.opentelemetry.io/otel/propagation"
 )
 
+// MapCarrier 返回需要向后传递的 traceparent 与 baggage，业务头部见 header.InjectMap
 func MapCarrier(ctx context.Context) map[string]string {
-	// 6. 向后传递 Header: traceparent
+	// 6. 向后传递 Header: traceparent、baggage
 	pp := propagation.NewCompositeTextMapPropagator(
 		propagation.TraceContext{},
+		propagation.Baggage{},
 	)
 
 	carrier := propagation.MapCarrier{}
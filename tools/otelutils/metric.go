@@ -0,0 +1,164 @@
+package otelutils
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/runtime"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"google.golang.org/grpc/credentials"
+)
+
+// Temporality OTLP 导出的聚合时间性
+type Temporality string
+
+const (
+	TemporalityCumulative Temporality = "cumulative"
+	// TemporalityDelta Counter 与 Histogram 使用 delta，UpDownCounter 仍使用 cumulative
+	TemporalityDelta Temporality = "delta"
+)
+
+type MetricsConfig struct {
+	// Protocol 与 Endpoint 同时设置时启用 OTLP 推送
+	Protocol Protocol
+	// Endpoint host:port
+	Endpoint string
+	// URLPath 仅 HTTP 协议使用，默认 /v1/metrics
+	URLPath string
+	// Insecure 使用明文连接，TLS 为空时使用系统根证书
+	Insecure bool
+	TLS      *tls.Config
+	Headers  map[string]string
+	Timeout  time.Duration
+	// Interval 推送间隔，默认 15s
+	Interval    time.Duration
+	Temporality Temporality
+
+	// Prometheus 启用 pull exporter，通过 Telemetry.PrometheusHandler 暴露
+	Prometheus bool
+	// Runtime 采集 Go runtime 指标（GC、内存、goroutine 等）
+	// 指标注册在本次创建的 MeterProvider 上，随其 Shutdown 停止采集
+	Runtime bool
+	// Process 采集进程指标（CPU 时间、内存、运行时长），注册方式同 Runtime
+	Process bool
+}
+
+// newMeterProvider 返回的 registry 仅在启用 Prometheus 时非空
+func newMeterProvider(ctx context.Context, res *resource.Resource, cfg MetricsConfig) (*sdkmetric.MeterProvider, *prometheus.Registry, error) {
+	opts := []sdkmetric.Option{sdkmetric.WithResource(res)}
+
+	if cfg.Protocol != "" && cfg.Endpoint != "" {
+		exporter, err := newOTLPMetricExporter(ctx, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		interval := cfg.Interval
+		if interval <= 0 {
+			interval = 15 * time.Second
+		}
+		opts = append(opts, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(interval))))
+	}
+
+	var registry *prometheus.Registry
+	if cfg.Prometheus {
+		registry = prometheus.NewRegistry()
+		exporter, err := otelprometheus.New(otelprometheus.WithRegisterer(registry))
+		if err != nil {
+			return nil, nil, xerror.Wrap(err)
+		}
+		opts = append(opts, sdkmetric.WithReader(exporter))
+	}
+
+	mp := sdkmetric.NewMeterProvider(opts...)
+
+	if cfg.Runtime {
+		if err := runtime.Start(runtime.WithMeterProvider(mp)); err != nil {
+			_ = mp.Shutdown(ctx)
+			return nil, nil, xerror.Wrap(err)
+		}
+	}
+
+	if cfg.Process {
+		if err := startProcessMetrics(mp); err != nil {
+			_ = mp.Shutdown(ctx)
+			return nil, nil, err
+		}
+	}
+
+	return mp, registry, nil
+}
+
+func newOTLPMetricExporter(ctx context.Context, cfg MetricsConfig) (sdkmetric.Exporter, error) {
+	selector := sdkmetric.DefaultTemporalitySelector
+	if cfg.Temporality == TemporalityDelta {
+		selector = deltaTemporality
+	}
+
+	switch cfg.Protocol {
+	case ProtocolHTTP:
+		opts := []otlpmetrichttp.Option{
+			otlpmetrichttp.WithEndpoint(cfg.Endpoint),
+			otlpmetrichttp.WithTemporalitySelector(selector),
+		}
+		if cfg.URLPath != "" {
+			opts = append(opts, otlpmetrichttp.WithURLPath(cfg.URLPath))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		} else if cfg.TLS != nil {
+			opts = append(opts, otlpmetrichttp.WithTLSClientConfig(cfg.TLS))
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetrichttp.WithHeaders(cfg.Headers))
+		}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlpmetrichttp.WithTimeout(cfg.Timeout))
+		}
+		exporter, err := otlpmetrichttp.New(ctx, opts...)
+		if err != nil {
+			return nil, xerror.Wrap(err)
+		}
+		return exporter, nil
+	case ProtocolGRPC:
+		opts := []otlpmetricgrpc.Option{
+			otlpmetricgrpc.WithEndpoint(cfg.Endpoint),
+			otlpmetricgrpc.WithTemporalitySelector(selector),
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		} else if cfg.TLS != nil {
+			opts = append(opts, otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(cfg.TLS)))
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetricgrpc.WithHeaders(cfg.Headers))
+		}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlpmetricgrpc.WithTimeout(cfg.Timeout))
+		}
+		exporter, err := otlpmetricgrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, xerror.Wrap(err)
+		}
+		return exporter, nil
+	default:
+		return nil, xerror.New("otelutils: unsupported metrics protocol " + string(cfg.Protocol))
+	}
+}
+
+func deltaTemporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	switch kind {
+	case sdkmetric.InstrumentKindUpDownCounter, sdkmetric.InstrumentKindObservableUpDownCounter:
+		return metricdata.CumulativeTemporality
+	default:
+		return metricdata.DeltaTemporality
+	}
+}
@@ -0,0 +1,64 @@
+package otelutils
+
+import (
+	"context"
+	"runtime/metrics"
+	"time"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	metricCPUTotal = "/cpu/classes/total:cpu-seconds"
+	metricCPUIdle  = "/cpu/classes/idle:cpu-seconds"
+	metricMemory   = "/memory/classes/total:bytes"
+)
+
+var processStart = time.Now()
+
+// startProcessMetrics 注册进程级指标：process.cpu.time、process.memory.usage 与 process.uptime
+// CPU 与内存来自 runtime/metrics，为 Go runtime 的估算值，不依赖平台相关的系统调用
+func startProcessMetrics(mp metric.MeterProvider) error {
+	meter := mp.Meter("kiwi-lib/otelutils/process")
+
+	cpuTime, err := meter.Float64ObservableCounter("process.cpu.time",
+		metric.WithDescription("CPU time used by the process, estimated by the Go runtime"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return xerror.Wrap(err)
+	}
+	memory, err := meter.Int64ObservableUpDownCounter("process.memory.usage",
+		metric.WithDescription("Memory mapped by the Go runtime"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return xerror.Wrap(err)
+	}
+	uptime, err := meter.Float64ObservableGauge("process.uptime",
+		metric.WithDescription("Time since the process started"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return xerror.Wrap(err)
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		samples := []metrics.Sample{{Name: metricCPUTotal}, {Name: metricCPUIdle}, {Name: metricMemory}}
+		metrics.Read(samples)
+
+		if samples[0].Value.Kind() == metrics.KindFloat64 && samples[1].Value.Kind() == metrics.KindFloat64 {
+			o.ObserveFloat64(cpuTime, samples[0].Value.Float64()-samples[1].Value.Float64())
+		}
+		if samples[2].Value.Kind() == metrics.KindUint64 {
+			o.ObserveInt64(memory, int64(samples[2].Value.Uint64()))
+		}
+		o.ObserveFloat64(uptime, time.Since(processStart).Seconds())
+		return nil
+	}, cpuTime, memory, uptime)
+	if err != nil {
+		return xerror.Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package otelutils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type Config struct {
+	Resource ResourceConfig
+	Trace    TraceConfig
+	Metrics  MetricsConfig
+	// Propagator 全局 propagator，默认 TraceContext + Baggage，需要传递业务头部时使用 header.Propagator{}
+	Propagator propagation.TextMapPropagator
+}
+
+// Telemetry Setup 创建的 provider，退出前需调用 Shutdown 推送缓冲中的数据
+type Telemetry struct {
+	TracerProvider *sdktrace.TracerProvider
+	MeterProvider  *sdkmetric.MeterProvider
+
+	registry *prometheus.Registry
+}
+
+var current atomic.Pointer[Telemetry]
+
+// Setup 创建 TracerProvider、MeterProvider 并设置为全局 provider 与 propagator
+func Setup(ctx context.Context, cfg Config) (*Telemetry, error) {
+	res, err := NewResource(ctx, cfg.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	tp, err := NewTracerProvider(ctx, res, cfg.Trace)
+	if err != nil {
+		return nil, err
+	}
+
+	mp, registry, err := newMeterProvider(ctx, res, cfg.Metrics)
+	if err != nil {
+		_ = tp.Shutdown(ctx)
+		return nil, err
+	}
+
+	prop := cfg.Propagator
+	if prop == nil {
+		prop = propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		)
+	}
+	otel.SetTextMapPropagator(prop)
+	otel.SetTracerProvider(tp)
+	otel.SetMeterProvider(mp)
+
+	t := &Telemetry{
+		TracerProvider: tp,
+		MeterProvider:  mp,
+		registry:       registry,
+	}
+	current.Store(t)
+
+	return t, nil
+}
+
+// Shutdown 推送剩余的 span 与指标并关闭 provider
+func (t *Telemetry) Shutdown(ctx context.Context) error {
+	return errors.Join(t.TracerProvider.Shutdown(ctx), t.MeterProvider.Shutdown(ctx))
+}
+
+// PrometheusHandler 返回 Prometheus 拉取指标的 handler，未启用 MetricsConfig.Prometheus 时返回 404
+func (t *Telemetry) PrometheusHandler() http.Handler {
+	if t.registry == nil {
+		return http.NotFoundHandler()
+	}
+	return promhttp.HandlerFor(t.registry, promhttp.HandlerOpts{})
+}
+
+// Shutdown 关闭最近一次 Setup 创建的 provider，未 Setup 时直接返回
+func Shutdown(ctx context.Context) error {
+	if t := current.Load(); t != nil {
+		return t.Shutdown(ctx)
+	}
+	return nil
+}
+
+// PrometheusHandler 返回最近一次 Setup 的 Prometheus handler
+func PrometheusHandler() http.Handler {
+	if t := current.Load(); t != nil {
+		return t.PrometheusHandler()
+	}
+	return http.NotFoundHandler()
+}
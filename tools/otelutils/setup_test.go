@@ -0,0 +1,77 @@
+package otelutils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetupPrometheus(t *testing.T) {
+	ctx := context.Background()
+	telemetry, err := Setup(ctx, Config{
+		Resource: ResourceConfig{ServiceName: "demo"},
+		Metrics:  MetricsConfig{Prometheus: true, Runtime: true, Process: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Shutdown(ctx)
+
+	counter, err := otel.Meter("test").Int64Counter("demo.requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter.Add(ctx, 3)
+
+	rec := httptest.NewRecorder()
+	PrometheusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	for _, want := range []string{"demo_requests_total", "process_runtime_go_goroutines", "process_uptime_seconds", "process_cpu_time_seconds_total", `service_name="demo"`} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("missing %q in:\n%s", want, body)
+		}
+	}
+
+	if err := telemetry.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetupUnsupportedProtocol(t *testing.T) {
+	_, err := Setup(context.Background(), Config{
+		Metrics: MetricsConfig{Protocol: "udp", Endpoint: "localhost:4318"},
+	})
+	if err == nil {
+		t.Fatal("expected error for unsupported protocol")
+	}
+}
+
+func TestSetupTwice(t *testing.T) {
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		telemetry, err := Setup(ctx, Config{
+			Resource: ResourceConfig{ServiceName: "demo"},
+			Metrics:  MetricsConfig{Prometheus: true, Runtime: true, Process: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rec := httptest.NewRecorder()
+		telemetry.PrometheusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		for _, want := range []string{"process_runtime_go_goroutines", "process_uptime_seconds"} {
+			if !strings.Contains(rec.Body.String(), want) {
+				t.Fatalf("setup %d: missing %q in:\n%s", i, want, rec.Body.String())
+			}
+		}
+
+		if err := telemetry.Shutdown(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
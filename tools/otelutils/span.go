@@ -0,0 +1,73 @@
+package otelutils
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "kiwi-lib"
+
+// 第三方服务调用 span 的通用属性
+const (
+	AttrProvider   = attribute.Key("client.provider")
+	AttrOperation  = attribute.Key("client.operation")
+	AttrResultCode = attribute.Key("client.result_code")
+)
+
+// StartSpan 创建内部 span，ctx 可以是 *gin.Context
+//
+//	ctx, span := otelutils.StartSpan(ctx, "job.sync_users")
+//	defer func() { otelutils.EndSpan(span, err) }()
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(spanParent(ctx), name, trace.WithAttributes(attrs...))
+}
+
+// StartClientSpan 为第三方服务调用创建 client span，span 名为 provider.operation
+func StartClientSpan(ctx context.Context, provider, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, AttrProvider.String(provider), AttrOperation.String(operation))
+	return otel.Tracer(tracerName).Start(spanParent(ctx), provider+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// RecordError 记录错误并将 span 状态置为 Error，xerror 的 kind、code 与堆栈记录为属性，err 为 nil 时不做处理
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	attrs := []attribute.KeyValue{}
+	if kind := xerror.KindOf(err); kind != "" {
+		attrs = append(attrs, attribute.String("error.type", string(kind)))
+	}
+	if code := xerror.CodeOf(err); code != 0 {
+		attrs = append(attrs, attribute.Int("error.code", code))
+	}
+	if stack := xerror.StackTrace(err); len(stack) > 0 {
+		attrs = append(attrs, attribute.String("exception.stacktrace", strings.Join(stack, "\n")))
+	}
+
+	span.RecordError(err, trace.WithAttributes(attrs...))
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// EndSpan 记录 err 后结束 span
+func EndSpan(span trace.Span, err error) {
+	RecordError(span, err)
+	span.End()
+}
+
+func spanParent(ctx context.Context) context.Context {
+	if c, ok := ctx.(*gin.Context); ok {
+		return c.Request.Context()
+	}
+	return ctx
+}
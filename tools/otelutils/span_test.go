@@ -0,0 +1,53 @@
+package otelutils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestClientSpanRecordsXerror(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(prev)
+
+	ctx, parent := StartSpan(context.Background(), "job")
+	_, span := StartClientSpan(ctx, "alibaba.oss", "PutObject")
+	EndSpan(span, xerror.WithCode(xerror.NewWithKind(xerror.KindUnavailable, "oss down"), 503))
+	EndSpan(parent, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("unexpected spans: %d", len(spans))
+	}
+
+	client := spans[0]
+	if client.Name() != "alibaba.oss.PutObject" || client.SpanKind() != trace.SpanKindClient {
+		t.Fatalf("unexpected span: %s %s", client.Name(), client.SpanKind())
+	}
+	if client.Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Fatal("client span should be a child of the job span")
+	}
+	if client.Status().Code != codes.Error || len(client.Events()) != 1 {
+		t.Fatalf("error not recorded: %+v", client.Status())
+	}
+
+	attrs := map[string]string{}
+	for _, kv := range client.Events()[0].Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs["error.type"] != string(xerror.KindUnavailable) || attrs["error.code"] != "503" || attrs["exception.stacktrace"] == "" {
+		t.Fatalf("unexpected event attributes: %v", attrs)
+	}
+	if spans[1].Status().Code == codes.Error {
+		t.Fatal("nil error should not mark span as error")
+	}
+}
@@ -0,0 +1,225 @@
+package otelutils
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"time"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/xerror"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"google.golang.org/grpc/credentials"
+)
+
+// Protocol OTLP 导出协议
+type Protocol string
+
+const (
+	ProtocolHTTP Protocol = "http"
+	ProtocolGRPC Protocol = "grpc"
+
+	// DebugStdout TraceConfig.DebugOutput 为该值时输出到标准输出，其余非空值视为文件路径
+	DebugStdout = "stdout"
+)
+
+// BatchConfig 批量 span 处理器配置，零值使用 SDK 默认值
+type BatchConfig struct {
+	MaxQueueSize       int
+	MaxExportBatchSize int
+	BatchTimeout       time.Duration
+	ExportTimeout      time.Duration
+}
+
+type TraceConfig struct {
+	// Protocol 与 Endpoint 同时设置时启用 OTLP 导出，否则 span 只用于生成 trace id
+	Protocol Protocol
+	// Endpoint host:port，如 otel-collector:4318
+	Endpoint string
+	// URLPath 仅 HTTP 协议使用，默认 /v1/traces
+	URLPath string
+	// Insecure 使用明文连接，TLS 为空时使用系统根证书
+	Insecure bool
+	TLS      *tls.Config
+	Headers  map[string]string
+	Timeout  time.Duration
+	Batch    BatchConfig
+
+	// SampleRatio 根 span 的采样率，子 span 跟随父 span 的采样决定，<= 0 或 >= 1 时全部采样
+	SampleRatio float64
+
+	// DebugOutput 本地调试时同步输出 span，可以是 DebugStdout 或文件路径
+	DebugOutput string
+}
+
+type ResourceConfig struct {
+	ServiceName    string
+	ServiceVersion string
+	Environment    string
+	Attributes     map[string]string
+}
+
+// NewResource 创建携带服务名、版本、环境与主机信息的 resource，同时读取 OTEL_RESOURCE_ATTRIBUTES
+func NewResource(ctx context.Context, cfg ResourceConfig) (*resource.Resource, error) {
+	attrs := []resource.Option{
+		resource.WithFromEnv(),
+		resource.WithHost(),
+		resource.WithTelemetrySDK(),
+	}
+
+	if cfg.ServiceName != "" {
+		attrs = append(attrs, resource.WithAttributes(semconv.ServiceName(cfg.ServiceName)))
+	}
+	if cfg.ServiceVersion != "" {
+		attrs = append(attrs, resource.WithAttributes(semconv.ServiceVersion(cfg.ServiceVersion)))
+	}
+	if cfg.Environment != "" {
+		attrs = append(attrs, resource.WithAttributes(semconv.DeploymentEnvironment(cfg.Environment)))
+	}
+	for k, v := range cfg.Attributes {
+		attrs = append(attrs, resource.WithAttributes(attribute.String(k, v)))
+	}
+
+	res, err := resource.New(ctx, attrs...)
+	if err != nil {
+		return nil, xerror.Wrap(err)
+	}
+	return res, nil
+}
+
+// NewTracerProvider 根据配置创建 TracerProvider
+// 未配置任何导出时仍返回可用的 TracerProvider，以便生成 trace id
+func NewTracerProvider(ctx context.Context, res *resource.Resource, cfg TraceConfig) (*sdktrace.TracerProvider, error) {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(newSampler(cfg.SampleRatio)),
+	}
+
+	if cfg.Protocol != "" && cfg.Endpoint != "" {
+		exporter, err := newOTLPTraceExporter(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter, batchOptions(cfg.Batch)...))
+	}
+
+	if cfg.DebugOutput != "" {
+		exporter, err := newDebugTraceExporter(cfg.DebugOutput)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithSyncer(exporter))
+	}
+
+	return sdktrace.NewTracerProvider(opts...), nil
+}
+
+func newSampler(ratio float64) sdktrace.Sampler {
+	if ratio <= 0 || ratio >= 1 {
+		return sdktrace.ParentBased(sdktrace.AlwaysSample())
+	}
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+}
+
+func newOTLPTraceExporter(ctx context.Context, cfg TraceConfig) (sdktrace.SpanExporter, error) {
+	switch cfg.Protocol {
+	case ProtocolHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.URLPath != "" {
+			opts = append(opts, otlptracehttp.WithURLPath(cfg.URLPath))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		} else if cfg.TLS != nil {
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(cfg.TLS))
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+		}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlptracehttp.WithTimeout(cfg.Timeout))
+		}
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, xerror.Wrap(err)
+		}
+		return exporter, nil
+	case ProtocolGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		} else if cfg.TLS != nil {
+			opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(cfg.TLS)))
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+		}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlptracegrpc.WithTimeout(cfg.Timeout))
+		}
+		exporter, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, xerror.Wrap(err)
+		}
+		return exporter, nil
+	default:
+		return nil, xerror.New("otelutils: unsupported trace protocol " + string(cfg.Protocol))
+	}
+}
+
+func batchOptions(cfg BatchConfig) []sdktrace.BatchSpanProcessorOption {
+	var opts []sdktrace.BatchSpanProcessorOption
+	if cfg.MaxQueueSize > 0 {
+		opts = append(opts, sdktrace.WithMaxQueueSize(cfg.MaxQueueSize))
+	}
+	if cfg.MaxExportBatchSize > 0 {
+		opts = append(opts, sdktrace.WithMaxExportBatchSize(cfg.MaxExportBatchSize))
+	}
+	if cfg.BatchTimeout > 0 {
+		opts = append(opts, sdktrace.WithBatchTimeout(cfg.BatchTimeout))
+	}
+	if cfg.ExportTimeout > 0 {
+		opts = append(opts, sdktrace.WithExportTimeout(cfg.ExportTimeout))
+	}
+	return opts
+}
+
+// fileExporter Shutdown 时关闭文件
+type fileExporter struct {
+	*stdouttrace.Exporter
+	f *os.File
+}
+
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	err := e.Exporter.Shutdown(ctx)
+	if cerr := e.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func newDebugTraceExporter(output string) (sdktrace.SpanExporter, error) {
+	if output == DebugStdout {
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, xerror.Wrap(err)
+		}
+		return exporter, nil
+	}
+
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, xerror.Wrap(err)
+	}
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+	if err != nil {
+		f.Close()
+		return nil, xerror.Wrap(err)
+	}
+	return &fileExporter{Exporter: exporter, f: f}, nil
+}
@@ -0,0 +1,69 @@
+package otelutils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracerProviderDebugFile(t *testing.T) {
+	ctx := context.Background()
+	res, err := NewResource(ctx, ResourceConfig{
+		ServiceName:    "demo",
+		ServiceVersion: "1.2.3",
+		Environment:    "test",
+		Attributes:     map[string]string{"team": "kiwi"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(t.TempDir(), "spans.json")
+	tp, err := NewTracerProvider(ctx, res, TraceConfig{DebugOutput: output})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, span := tp.Tracer("test").Start(ctx, "debug-span")
+	span.End()
+	if err := tp.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"debug-span", "1.2.3", "deployment.environment", "kiwi"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("missing %q in %s", want, data)
+		}
+	}
+}
+
+func TestParentBasedRatioSampler(t *testing.T) {
+	ctx := context.Background()
+	res, _ := NewResource(ctx, ResourceConfig{ServiceName: "demo"})
+	tp, err := NewTracerProvider(ctx, res, TraceConfig{SampleRatio: 0.000001})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tp.Shutdown(ctx)
+
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	_, span := tp.Tracer("test").Start(trace.ContextWithRemoteSpanContext(ctx, parent), "child")
+	defer span.End()
+
+	if !span.SpanContext().IsSampled() {
+		t.Fatal("child of sampled parent should be sampled")
+	}
+}
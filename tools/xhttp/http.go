@@ -5,13 +5,17 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/breaker"
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/header"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 // HTTPError represents an HTTP error, including status code and response body.
+// DoJSON 返回的 Body 最多保留 MaxErrorBodySize 字节
 type HTTPError struct {
 	StatusCode int
+	Header     http.Header
 	Body       []byte
 }
 
@@ -23,13 +27,11 @@ type OtelTransport struct {
 	Transport http.RoundTripper
 }
 
-// RoundTrip adds headers and delegates to the original Transport.
+// RoundTrip adds traceparent and delegates to the original Transport.
+// baggage 与业务头部由 PropagationTransport 按 header.Policy 决定是否传递
 func (ot *OtelTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-
-	headers := otelutils.MapCarrier(req.Context())
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
+	req = req.Clone(req.Context())
+	propagation.TraceContext{}.Inject(req.Context(), propagation.HeaderCarrier(req.Header))
 
 	return ot.Transport.RoundTrip(req)
 }
@@ -40,12 +42,45 @@ func NewOtelTransport(base http.RoundTripper) *OtelTransport {
 	}
 
 	return &OtelTransport{
-		Transport: otelhttp.NewTransport(base),
+		// 只注入 traceparent，不使用可能包含业务头部的全局 propagator
+		Transport: otelhttp.NewTransport(base, otelhttp.WithPropagators(propagation.TraceContext{})),
+	}
+}
+
+// PropagationTransport 按 header.Policy 为出站请求添加透传头部
+type PropagationTransport struct {
+	Transport http.RoundTripper
+	Policy    *header.Policy
+}
+
+// RoundTrip 复制请求后按目标 host 应用策略，不修改调用方的请求
+func (pt *PropagationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	pt.Policy.Apply(req.Context(), req.URL.Hostname(), req.Header)
+
+	return pt.Transport.RoundTrip(req)
+}
+
+// NewPropagationTransport policy 为空时使用 header.DefaultPolicy
+func NewPropagationTransport(base http.RoundTripper, policy *header.Policy) *PropagationTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if policy == nil {
+		policy = header.DefaultPolicy()
+	}
+
+	return &PropagationTransport{
+		Transport: base,
+		Policy:    policy,
 	}
 }
 
 type Client struct {
 	timeout time.Duration
+	policy  *header.Policy
+	retry   *RetryPolicy
+	breaker *breaker.Breaker
 	http.Client
 }
 
@@ -57,6 +92,28 @@ func WithTimeout(timeout time.Duration) ClientOption {
 	}
 }
 
+// WithPropagationPolicy 按策略透传 context 中的业务头部，默认不透传
+func WithPropagationPolicy(policy *header.Policy) ClientOption {
+	return func(c *Client) {
+		c.policy = policy
+	}
+}
+
+// WithRetry 按策略重试幂等请求，Client 的超时包含所有重试
+func WithRetry(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retry = &policy
+	}
+}
+
+// WithBreaker 经过熔断器发送请求，熔断时直接返回 breaker.ErrOpenState
+// 同时启用重试时，一次请求的所有重试作为一个结果统计
+func WithBreaker(b *breaker.Breaker) ClientOption {
+	return func(c *Client) {
+		c.breaker = b
+	}
+}
+
 func NewClient(opts ...ClientOption) *Client {
 	httpClient := &Client{
 		timeout: 5 * time.Second, // default timeout
@@ -65,8 +122,19 @@ func NewClient(opts ...ClientOption) *Client {
 		opt(httpClient)
 	}
 
+	var transport http.RoundTripper = NewOtelTransport(http.DefaultTransport)
+	if httpClient.policy != nil {
+		transport = NewPropagationTransport(transport, httpClient.policy)
+	}
+	if httpClient.retry != nil {
+		transport = NewRetryTransport(transport, *httpClient.retry)
+	}
+	if httpClient.breaker != nil {
+		transport = breaker.NewTransport(transport, httpClient.breaker)
+	}
+
 	httpClient.Client = http.Client{
-		Transport: NewOtelTransport(http.DefaultTransport),
+		Transport: transport,
 		Timeout:   httpClient.timeout,
 	}
 
@@ -0,0 +1,84 @@
+package xhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/header"
+	"go.opentelemetry.io/otel"
+)
+
+func TestPropagationTransport(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	in := httptest.NewRequest(http.MethodGet, "/", nil)
+	in.Header.Set(header.HeaderAuthorization, "Bearer secret")
+	in.Header.Set(header.HeaderXRequestID, "r-1")
+	ctx := header.ExtractHeadersToContext(context.Background(), in)
+
+	client := NewClient(WithPropagationPolicy(&header.Policy{
+		Default: header.Rule{Allow: []string{header.HeaderXRequestID}},
+	}))
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got.Get(header.HeaderXRequestID) != "r-1" || got.Get(header.HeaderAuthorization) != "" {
+		t.Fatalf("unexpected headers: %v", got)
+	}
+	if req.Header.Get(header.HeaderXRequestID) != "" {
+		t.Fatal("transport should not modify the caller's request")
+	}
+}
+
+func TestPolicyControlsBaggageAndCredentials(t *testing.T) {
+	header.BaggageHeaders = map[string]string{header.HeaderXUserID: "user.id"}
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(header.Propagator{})
+	defer func() {
+		header.BaggageHeaders = map[string]string{}
+		otel.SetTextMapPropagator(prev)
+	}()
+
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	in := httptest.NewRequest(http.MethodGet, "/", nil)
+	in.Header.Set(header.HeaderAuthorization, "Bearer secret")
+	in.Header.Set(header.HeaderXUserID, "u-1")
+	in.Header.Set(header.HeaderXRequestID, "r-1")
+	in.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	ctx := header.ExtractHeadersToContext(context.Background(), in)
+
+	client := NewClient(WithPropagationPolicy(&header.Policy{
+		Default: header.Rule{Allow: []string{header.HeaderXRequestID}, Baggage: false},
+	}))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	for _, key := range []string{header.HeaderAuthorization, header.HeaderXUserID, "baggage"} {
+		if v := got.Get(key); v != "" {
+			t.Errorf("%s should not be sent, got %q", key, v)
+		}
+	}
+	if got.Get(header.HeaderXRequestID) != "r-1" || got.Get("traceparent") == "" {
+		t.Fatalf("unexpected headers: %v", got)
+	}
+}
@@ -0,0 +1,175 @@
+package xhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/header"
+)
+
+const (
+	// DefaultMaxResponseSize DoJSON 默认允许的最大响应体，10MB
+	DefaultMaxResponseSize = 10 << 20
+	// MaxErrorBodySize HTTPError 中保留的响应体长度
+	MaxErrorBodySize = 4 << 10
+)
+
+// ErrResponseTooLarge 2xx 响应体超过 WithMaxResponseSize 设置的大小，非 2xx 响应返回截断后的 *HTTPError
+var ErrResponseTooLarge = errors.New("xhttp: response body too large")
+
+// Doer *http.Client 与 *Client 都实现了 Doer
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// ErrorDecoder 将非 2xx 响应解析为服务商的错误类型，返回 nil 时使用 *HTTPError
+type ErrorDecoder func(resp *http.Response, body []byte) error
+
+type requestOptions struct {
+	header          http.Header
+	policy          *header.Policy
+	maxResponseSize int64
+	errorDecoder    ErrorDecoder
+}
+
+type RequestOption func(*requestOptions)
+
+func WithHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		o.header.Set(key, value)
+	}
+}
+
+func WithBearerToken(token string) RequestOption {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithRequestPolicy 设置 context 头部的透传策略，默认 header.DefaultPolicy
+func WithRequestPolicy(policy *header.Policy) RequestOption {
+	return func(o *requestOptions) {
+		o.policy = policy
+	}
+}
+
+// WithMaxResponseSize 默认 DefaultMaxResponseSize
+func WithMaxResponseSize(size int64) RequestOption {
+	return func(o *requestOptions) {
+		o.maxResponseSize = size
+	}
+}
+
+func WithErrorDecoder(decoder ErrorDecoder) RequestOption {
+	return func(o *requestOptions) {
+		o.errorDecoder = decoder
+	}
+}
+
+// DoJSON 将 req 编码为 JSON 发送，2xx 响应解码为 Resp，响应体为空时返回 Resp 的零值
+// req 为 nil 时不发送请求体；非 2xx 响应返回 ErrorDecoder 的结果或 *HTTPError
+//
+//	resp, err := xhttp.DoJSON[SendRequest, SendResponse](ctx, client, http.MethodPost, url, req,
+//		xhttp.WithBearerToken(apiKey))
+func DoJSON[Req, Resp any](ctx context.Context, client Doer, method, url string, req Req, options ...RequestOption) (*Resp, error) {
+	opts := requestOptions{
+		header:          make(http.Header),
+		maxResponseSize: DefaultMaxResponseSize,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.policy == nil {
+		opts.policy = header.DefaultPolicy()
+	}
+
+	var body io.Reader
+	if !isNil(req) {
+		data, err := json.Marshal(req)
+		if err != nil {
+			return nil, fmt.Errorf("xhttp: marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	opts.policy.Apply(ctx, httpReq.URL.Hostname(), httpReq.Header)
+	for key, values := range opts.header {
+		httpReq.Header[key] = values
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, opts.maxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	tooLarge := int64(len(data)) > opts.maxResponseSize
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// 错误响应过大时不再解析，返回截断后的 HTTPError 以保留状态码
+		if tooLarge {
+			return nil, newHTTPError(resp, data)
+		}
+		if opts.errorDecoder != nil {
+			if err := opts.errorDecoder(resp, data); err != nil {
+				return nil, err
+			}
+		}
+		return nil, newHTTPError(resp, data)
+	}
+	if tooLarge {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrResponseTooLarge, opts.maxResponseSize)
+	}
+
+	result := new(Resp)
+	if len(bytes.TrimSpace(data)) == 0 {
+		return result, nil
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("xhttp: decode response: %w, body: %s", err, truncate(data))
+	}
+	return result, nil
+}
+
+func newHTTPError(resp *http.Response, body []byte) *HTTPError {
+	return &HTTPError{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       truncate(body),
+	}
+}
+
+func truncate(body []byte) []byte {
+	if len(body) > MaxErrorBodySize {
+		return body[:MaxErrorBodySize]
+	}
+	return body
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
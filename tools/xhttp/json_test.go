@@ -0,0 +1,134 @@
+package xhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/header"
+)
+
+type echoRequest struct {
+	Name string `json:"name"`
+}
+
+type echoResponse struct {
+	Greeting string `json:"greeting"`
+	Auth     string `json:"auth"`
+	Request  string `json:"request_id"`
+}
+
+func TestDoJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		var req echoRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		_ = json.NewEncoder(w).Encode(echoResponse{
+			Greeting: "hello " + req.Name,
+			Auth:     r.Header.Get("Authorization"),
+			Request:  r.Header.Get(header.HeaderXRequestID),
+		})
+	}))
+	defer srv.Close()
+
+	in := httptest.NewRequest(http.MethodGet, "/", nil)
+	in.Header.Set(header.HeaderXRequestID, "req-1")
+	ctx := header.ExtractHeadersToContext(context.Background(), in)
+	resp, err := DoJSON[echoRequest, echoResponse](ctx, http.DefaultClient, http.MethodPost, srv.URL, echoRequest{Name: "kiwi"},
+		WithBearerToken("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Greeting != "hello kiwi" || resp.Auth != "Bearer secret" || resp.Request != "req-1" {
+		t.Fatalf("resp = %+v", resp)
+	}
+}
+
+func TestDoJSONHTTPError(t *testing.T) {
+	long := strings.Repeat("x", MaxErrorBodySize*2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > 0 {
+			t.Errorf("nil request should not send body")
+		}
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(long))
+	}))
+	defer srv.Close()
+
+	_, err := DoJSON[any, echoResponse](context.Background(), http.DefaultClient, http.MethodGet, srv.URL, nil)
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("err = %v, want *HTTPError", err)
+	}
+	if httpErr.StatusCode != http.StatusBadGateway || len(httpErr.Body) != MaxErrorBodySize {
+		t.Fatalf("status = %d, body len = %d", httpErr.StatusCode, len(httpErr.Body))
+	}
+}
+
+type providerError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *providerError) Error() string {
+	return fmt.Sprintf("provider error %d: %s", e.Code, e.Message)
+}
+
+func TestDoJSONErrorDecoder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":1001,"message":"invalid name"}`))
+	}))
+	defer srv.Close()
+
+	decoder := func(resp *http.Response, body []byte) error {
+		var e providerError
+		if json.Unmarshal(body, &e) != nil || e.Code == 0 {
+			return nil
+		}
+		return &e
+	}
+	_, err := DoJSON[echoRequest, echoResponse](context.Background(), http.DefaultClient, http.MethodPost, srv.URL, echoRequest{},
+		WithErrorDecoder(decoder))
+	var pe *providerError
+	if !errors.As(err, &pe) || pe.Code != 1001 {
+		t.Fatalf("err = %v, want providerError", err)
+	}
+}
+
+func TestDoJSONMaxResponseSize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"greeting":"` + strings.Repeat("x", 100) + `"}`))
+	}))
+	defer srv.Close()
+
+	_, err := DoJSON[any, echoResponse](context.Background(), http.DefaultClient, http.MethodGet, srv.URL, nil,
+		WithMaxResponseSize(64))
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("err = %v, want ErrResponseTooLarge", err)
+	}
+}
+
+func TestDoJSONLargeErrorResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer srv.Close()
+
+	_, err := DoJSON[any, echoResponse](context.Background(), http.DefaultClient, http.MethodGet, srv.URL, nil,
+		WithMaxResponseSize(64))
+	var he *HTTPError
+	if !errors.As(err, &he) || he.StatusCode != http.StatusBadGateway || len(he.Body) == 0 {
+		t.Fatalf("err = %v, want *HTTPError", err)
+	}
+}
@@ -0,0 +1,285 @@
+package xhttp
+
+import (
+	"bytes"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Yet-Another-AI-Project/kiwi-lib/tools/otelutils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxBufferedBody 没有 GetBody 的请求体最多缓存的字节数，超过时不重试
+const maxBufferedBody = 1 << 20
+
+type RetryPolicy struct {
+	// MaxAttempts 最大尝试次数（包含首次请求），默认 3
+	MaxAttempts int
+	// BaseDelay 与 MaxDelay 指数退避的初始与最大间隔，实际间隔在 [0, min(MaxDelay, BaseDelay*2^n)) 间随机
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// RetryStatus 需要重试的状态码，默认 429、502、503、504
+	RetryStatus []int
+	// IdempotencyHeaders 携带任一头部的非幂等请求（如 POST）也会重试，默认 Idempotency-Key、X-Idempotency-Key
+	IdempotencyHeaders []string
+
+	// BudgetRatio 每个请求为重试预算增加的额度，默认 0.1，即重试数约不超过请求数的 10%
+	BudgetRatio float64
+	// BudgetMax 重试预算上限，也是初始额度，默认 10
+	BudgetMax float64
+}
+
+// RetryTransport 按 RetryPolicy 重试请求
+// 重试前通过 GetBody 重建请求体，没有 GetBody 时缓存不超过 1MB 的请求体
+type RetryTransport struct {
+	Transport http.RoundTripper
+
+	policy  RetryPolicy
+	budget  *retryBudget
+	retries metric.Int64Counter
+}
+
+func NewRetryTransport(base http.RoundTripper, policy RetryPolicy) *RetryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = 100 * time.Millisecond
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = 5 * time.Second
+	}
+	if policy.RetryStatus == nil {
+		policy.RetryStatus = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	}
+	if policy.IdempotencyHeaders == nil {
+		policy.IdempotencyHeaders = []string{"Idempotency-Key", "X-Idempotency-Key"}
+	}
+	if policy.BudgetRatio <= 0 {
+		policy.BudgetRatio = 0.1
+	}
+	if policy.BudgetMax <= 0 {
+		policy.BudgetMax = 10
+	}
+
+	retries, err := otel.Meter("kiwi-lib/xhttp").Int64Counter(
+		"http.client.request.retries",
+		metric.WithDescription("Number of retried HTTP client requests."),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return &RetryTransport{
+		Transport: base,
+		policy:    policy,
+		budget:    &retryBudget{ratio: policy.BudgetRatio, max: policy.BudgetMax, tokens: policy.BudgetMax},
+		retries:   retries,
+	}
+}
+
+func (rt *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.budget.deposit()
+
+	retryable := rt.retryable(req)
+	if retryable && req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		var err error
+		if req, retryable, err = bufferBody(req); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 {
+			attemptReq = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		resp, err := rt.attempt(attemptReq, attempt)
+
+		reason, retry := rt.shouldRetry(resp, err)
+		if !retryable || !retry || attempt >= rt.policy.MaxAttempts || ctx.Err() != nil {
+			return resp, err
+		}
+
+		delay := rt.backoff(attempt)
+		if after, ok := retryAfter(resp); ok {
+			// 服务端要求的等待时间超过上限时不再重试
+			if after > rt.policy.MaxDelay {
+				return resp, err
+			}
+			delay = max(delay, after)
+		}
+
+		if !rt.budget.withdraw() {
+			rt.record(req, reason, "budget_exhausted")
+			return resp, err
+		}
+		rt.record(req, reason, "retried")
+		trace.SpanFromContext(ctx).AddEvent("http.retry", trace.WithAttributes(
+			attribute.Int("http.request.resend_count", attempt),
+			attribute.String("retry.reason", reason),
+			attribute.Int64("retry.delay_ms", delay.Milliseconds()),
+		))
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt 发送一次请求，每次尝试记录为独立的 span
+func (rt *RetryTransport) attempt(req *http.Request, attempt int) (resp *http.Response, err error) {
+	ctx, span := otelutils.StartSpan(req.Context(), "HTTP attempt", attribute.Int("http.request.resend_count", attempt-1))
+	defer func() {
+		if resp != nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		}
+		otelutils.EndSpan(span, err)
+	}()
+
+	return rt.Transport.RoundTrip(req.WithContext(ctx))
+}
+
+// retryable 幂等方法或携带幂等键的请求可以重试
+func (rt *RetryTransport) retryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	for _, h := range rt.policy.IdempotencyHeaders {
+		if req.Header.Get(h) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (rt *RetryTransport) shouldRetry(resp *http.Response, err error) (string, bool) {
+	if err != nil {
+		return "error", true
+	}
+	for _, status := range rt.policy.RetryStatus {
+		if resp.StatusCode == status {
+			return strconv.Itoa(status), true
+		}
+	}
+	return "", false
+}
+
+// backoff full jitter
+func (rt *RetryTransport) backoff(attempt int) time.Duration {
+	ceiling := rt.policy.MaxDelay
+	if shift := attempt - 1; shift < 32 {
+		if d := rt.policy.BaseDelay << shift; d > 0 && d < ceiling {
+			ceiling = d
+		}
+	}
+	return time.Duration(rand.Int64N(int64(ceiling)) + 1)
+}
+
+func (rt *RetryTransport) record(req *http.Request, reason, outcome string) {
+	if rt.retries == nil {
+		return
+	}
+	rt.retries.Add(req.Context(), 1, metric.WithAttributes(
+		attribute.String("http.request.method", req.Method),
+		attribute.String("server.address", req.URL.Hostname()),
+		attribute.String("retry.reason", reason),
+		attribute.String("retry.outcome", outcome),
+	))
+}
+
+// retryAfter 解析秒数或 HTTP 日期格式的 Retry-After
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// bufferBody 缓存请求体并设置 GetBody，超过 maxBufferedBody 时原样发送且不重试
+func bufferBody(req *http.Request) (*http.Request, bool, error) {
+	data, err := io.ReadAll(io.LimitReader(req.Body, maxBufferedBody+1))
+	if err != nil {
+		req.Body.Close()
+		return nil, false, err
+	}
+
+	req = req.Clone(req.Context())
+	if len(data) > maxBufferedBody {
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), req.Body), req.Body}
+		return req, false, nil
+	}
+
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return req, true, nil
+}
+
+// retryBudget 每个请求存入 ratio，每次重试取出 1，防止下游故障时重试放大流量
+type retryBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	max    float64
+	tokens float64
+}
+
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	b.tokens = min(b.max, b.tokens+b.ratio)
+	b.mu.Unlock()
+}
+
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,87 @@
+package xhttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("body not rewound: %q", body)
+		}
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewRetryTransport(nil, RetryPolicy{BaseDelay: time.Millisecond})}
+
+	// 没有 GetBody 的请求体会被缓存
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, io.NopCloser(strings.NewReader("payload")))
+	req.Header.Set("Idempotency-Key", "k-1")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Fatalf("unexpected result: status %d after %d calls", resp.StatusCode, calls.Load())
+	}
+
+	// 没有幂等键的 POST 不重试
+	calls.Store(0)
+	resp, err = client.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Fatalf("non-idempotent request retried: %d calls", calls.Load())
+	}
+}
+
+func TestRetryBudgetAndRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path == "/later" {
+			w.Header().Set("Retry-After", "3600")
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewRetryTransport(nil, RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    10 * time.Millisecond,
+		BudgetMax:   2,
+	})}
+
+	// Retry-After 超过 MaxDelay 时直接返回
+	resp, _ := client.Get(srv.URL + "/later")
+	resp.Body.Close()
+	if calls.Load() != 1 {
+		t.Fatalf("should not wait for long Retry-After: %d calls", calls.Load())
+	}
+
+	// 预算只允许 2 次重试
+	calls.Store(0)
+	resp, _ = client.Get(srv.URL)
+	resp.Body.Close()
+	if calls.Load() != 3 {
+		t.Fatalf("retry budget not applied: %d calls", calls.Load())
+	}
+}
@@ -0,0 +1,288 @@
+package xhttp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DoneSentinel OpenAI 兼容接口表示流结束的 data
+const DoneSentinel = "[DONE]"
+
+var (
+	// ErrEventTimeout 超过 WithEventTimeout 设置的时间没有收到事件
+	ErrEventTimeout = errors.New("xhttp: timed out waiting for event")
+	// ErrNotEventStream 响应的 Content-Type 不是 text/event-stream
+	ErrNotEventStream = errors.New("xhttp: response is not an event stream")
+)
+
+// Event text/event-stream 中的一个事件
+type Event struct {
+	ID string
+	// Event 事件类型，未设置时为空，按规范等同于 message
+	Event string
+	Data  []byte
+	// Retry 服务端通过 retry 字段建议的重连间隔
+	Retry time.Duration
+}
+
+// EventReader 按 SSE 规范解析 text/event-stream
+type EventReader struct {
+	reader *bufio.Reader
+}
+
+func NewEventReader(r io.Reader) *EventReader {
+	return &EventReader{reader: bufio.NewReader(r)}
+}
+
+// Next 返回下一个事件，流结束时返回 io.EOF
+// 流末尾缺少空行的事件也会返回，兼容部分不规范的服务端
+func (r *EventReader) Next() (*Event, error) {
+	event := &Event{}
+	var data bytes.Buffer
+	hasData := false
+
+	for {
+		line, err := r.reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			if err == io.EOF && hasData {
+				event.Data = bytes.TrimSuffix(data.Bytes(), []byte("\n"))
+				return event, nil
+			}
+			return nil, err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		if line == "" {
+			if !hasData {
+				// 没有 data 的事件不分发，保留 id 与 retry 供后续事件使用
+				event.Event = ""
+				continue
+			}
+			event.Data = bytes.TrimSuffix(data.Bytes(), []byte("\n"))
+			return event, nil
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				event.ID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				event.Retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+type streamOptions struct {
+	maxReconnects  int
+	reconnectDelay time.Duration
+	eventTimeout   time.Duration
+	lastEventID    string
+}
+
+type StreamOption func(*streamOptions)
+
+// WithReconnect 连接或读取出错时最多重连 max 次，请求头携带最后收到的 Last-Event-ID
+// delay 为默认重连间隔，服务端的 retry 字段优先；流正常结束或 4xx 响应时不重连
+func WithReconnect(max int, delay time.Duration) StreamOption {
+	return func(o *streamOptions) {
+		o.maxReconnects = max
+		o.reconnectDelay = delay
+	}
+}
+
+// WithEventTimeout 两个事件之间的最长等待时间，超时后断开连接并返回 ErrEventTimeout
+// 流式请求不应设置 http.Client.Timeout，由该选项控制空闲超时
+func WithEventTimeout(timeout time.Duration) StreamOption {
+	return func(o *streamOptions) {
+		o.eventTimeout = timeout
+	}
+}
+
+// WithLastEventID 设置首次请求的 Last-Event-ID
+func WithLastEventID(id string) StreamOption {
+	return func(o *streamOptions) {
+		o.lastEventID = id
+	}
+}
+
+// Stream 发送请求并逐个返回事件，出错时返回错误后结束
+// newRequest 在每次连接时调用，POST 请求需要在其中重建请求体
+//
+//	for event, err := range xhttp.Stream(ctx, client, newRequest, xhttp.WithEventTimeout(time.Minute)) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func Stream(ctx context.Context, client Doer, newRequest func(ctx context.Context) (*http.Request, error), options ...StreamOption) iter.Seq2[*Event, error] {
+	opts := streamOptions{
+		reconnectDelay: time.Second,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return func(yield func(*Event, error) bool) {
+		lastEventID := opts.lastEventID
+		delay := opts.reconnectDelay
+
+		for attempt := 0; ; attempt++ {
+			if attempt > 0 {
+				timer := time.NewTimer(delay)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					yield(nil, ctx.Err())
+					return
+				case <-timer.C:
+				}
+			}
+
+			err := stream(ctx, client, newRequest, lastEventID, opts.eventTimeout, func(event *Event) bool {
+				if event.ID != "" {
+					lastEventID = event.ID
+				}
+				if event.Retry > 0 {
+					delay = event.Retry
+				}
+				return yield(event, nil)
+			})
+			if err == nil || errors.Is(err, errStopped) {
+				return
+			}
+			if attempt >= opts.maxReconnects || ctx.Err() != nil || !reconnectable(err) {
+				yield(nil, err)
+				return
+			}
+		}
+	}
+}
+
+// StreamJSON 将事件的 data 解码为 T，收到 DoneSentinel 时结束
+func StreamJSON[T any](ctx context.Context, client Doer, newRequest func(ctx context.Context) (*http.Request, error), options ...StreamOption) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		for event, err := range Stream(ctx, client, newRequest, options...) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if string(bytes.TrimSpace(event.Data)) == DoneSentinel {
+				return
+			}
+			v := new(T)
+			if err := json.Unmarshal(event.Data, v); err != nil {
+				yield(nil, fmt.Errorf("xhttp: decode event: %w, data: %s", err, truncate(event.Data)))
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
+
+// errStopped 调用方停止迭代
+var errStopped = errors.New("xhttp: stream stopped")
+
+// stream 建立一次连接并分发事件，流正常结束时返回 nil
+func stream(ctx context.Context, client Doer, newRequest func(ctx context.Context) (*http.Request, error), lastEventID string, eventTimeout time.Duration, emit func(*Event) bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var timedOut atomic.Bool
+	if eventTimeout > 0 {
+		timer := time.AfterFunc(eventTimeout, func() {
+			timedOut.Store(true)
+			cancel()
+		})
+		defer timer.Stop()
+		emit = func(emit func(*Event) bool) func(*Event) bool {
+			return func(event *Event) bool {
+				timer.Stop()
+				ok := emit(event)
+				timer.Reset(eventTimeout)
+				return ok
+			}
+		}(emit)
+	}
+
+	req, err := newRequest(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if timedOut.Load() {
+			return ErrEventTimeout
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MaxErrorBodySize))
+		return newHTTPError(resp, body)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MaxErrorBodySize))
+		return fmt.Errorf("%w: %s, body: %s", ErrNotEventStream, resp.Header.Get("Content-Type"), body)
+	}
+
+	reader := NewEventReader(resp.Body)
+	for {
+		event, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if timedOut.Load() {
+				return ErrEventTimeout
+			}
+			return err
+		}
+		if !emit(event) {
+			return errStopped
+		}
+	}
+}
+
+// reconnectable 4xx 响应与非事件流响应不重连
+func reconnectable(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500 || httpErr.StatusCode == http.StatusTooManyRequests
+	}
+	return !errors.Is(err, ErrNotEventStream)
+}
@@ -0,0 +1,150 @@
+package xhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEventReader(t *testing.T) {
+	stream := ": comment\r\n" +
+		"event: message\n" +
+		"id: 1\n" +
+		"data: line1\n" +
+		"data:line2\n" +
+		"\n" +
+		"retry: 2500\n" +
+		"\n" +
+		"data: tail"
+
+	reader := NewEventReader(strings.NewReader(stream))
+	event, err := reader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Event != "message" || event.ID != "1" || string(event.Data) != "line1\nline2" {
+		t.Fatalf("event = %+v", event)
+	}
+
+	event, err = reader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(event.Data) != "tail" || event.Retry != 2500*time.Millisecond {
+		t.Fatalf("event = %+v", event)
+	}
+
+	if _, err := reader.Next(); err != io.EOF {
+		t.Fatalf("err = %v, want io.EOF", err)
+	}
+}
+
+type chunk struct {
+	N int `json:"n"`
+}
+
+func newGet(url string) func(ctx context.Context) (*http.Request, error) {
+	return func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	}
+}
+
+func TestStreamJSONDone(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 1; i <= 2; i++ {
+			fmt.Fprintf(w, "data: {\"n\":%d}\n\n", i)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+		fmt.Fprint(w, "data: {\"n\":3}\n\n")
+	}))
+	defer srv.Close()
+
+	var got []int
+	for c, err := range StreamJSON[chunk](context.Background(), http.DefaultClient, newGet(srv.URL)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, c.N)
+	}
+	if fmt.Sprint(got) != "[1 2]" {
+		t.Fatalf("got = %v", got)
+	}
+}
+
+func TestStreamReconnectWithLastEventID(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		if calls.Add(1) == 1 {
+			fmt.Fprint(w, "retry: 1\nid: 7\ndata: first\n\n")
+			w.(http.Flusher).Flush()
+			// 中断连接，模拟网络错误
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", r.Header.Get("Last-Event-ID"))
+	}))
+	defer srv.Close()
+
+	var got []string
+	for event, err := range Stream(context.Background(), http.DefaultClient, newGet(srv.URL), WithReconnect(1, time.Hour)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(event.Data))
+	}
+	if fmt.Sprint(got) != "[first 7]" {
+		t.Fatalf("got = %v", got)
+	}
+}
+
+func TestStreamEventTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	var events int
+	var lastErr error
+	for _, err := range Stream(context.Background(), http.DefaultClient, newGet(srv.URL), WithEventTimeout(50*time.Millisecond)) {
+		if err != nil {
+			lastErr = err
+			break
+		}
+		events++
+	}
+	if events != 1 || !errors.Is(lastErr, ErrEventTimeout) {
+		t.Fatalf("events = %d, err = %v", events, lastErr)
+	}
+}
+
+func TestStreamHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"bad key"}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	var errs int
+	for _, err := range Stream(context.Background(), http.DefaultClient, newGet(srv.URL), WithReconnect(3, time.Millisecond)) {
+		errs++
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("err = %v, want 401 HTTPError", err)
+		}
+	}
+	if errs != 1 {
+		t.Fatalf("got %d errors, want 1", errs)
+	}
+}
@@ -0,0 +1,209 @@
+package xhttptest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Redacted 替换敏感信息的占位符
+const Redacted = "REDACTED"
+
+var (
+	// DefaultScrubHeaders 默认脱敏的头部
+	DefaultScrubHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "Api-Key", "X-Auth-Token"}
+	// DefaultScrubQuery 默认脱敏的查询参数
+	DefaultScrubQuery = []string{"access_token", "api_key", "apikey", "key", "token", "secret", "signature", "Signature"}
+)
+
+// Cassette 录制的请求与响应，按录制顺序保存
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       Body        `json:"body,omitempty"`
+}
+
+// Body 文本内容按原样保存，二进制内容保存为 base64
+type Body []byte
+
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = Body(s)
+		return nil
+	}
+	var encoded map[string]string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded["base64"])
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// LoadCassette 读取 cassette 文件
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Save 保存 cassette，自动创建目录
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Scrubber 保存前对录制内容脱敏
+type Scrubber func(*Interaction)
+
+// ScrubHeaders 将请求与响应中的指定头部替换为 Redacted
+func ScrubHeaders(names ...string) Scrubber {
+	return func(i *Interaction) {
+		for _, name := range names {
+			for _, h := range []http.Header{i.Request.Header, i.Response.Header} {
+				if h.Get(name) != "" {
+					h.Set(name, Redacted)
+				}
+			}
+		}
+	}
+}
+
+// ScrubQuery 将请求 URL 中的指定查询参数替换为 Redacted
+func ScrubQuery(params ...string) Scrubber {
+	return func(i *Interaction) {
+		i.Request.URL = scrubURL(i.Request.URL, params)
+	}
+}
+
+// ScrubJSONFields 将请求与响应 JSON 体中任意层级的指定字段替换为 Redacted，非 JSON 内容不处理
+func ScrubJSONFields(fields ...string) Scrubber {
+	return func(i *Interaction) {
+		i.Request.Body = scrubJSON(i.Request.Body, fields)
+		i.Response.Body = scrubJSON(i.Response.Body, fields)
+	}
+}
+
+// ScrubString 将请求与响应中出现的 secret 替换为 Redacted，用于签名、密钥等出现在路径或正文中的值
+func ScrubString(secrets ...string) Scrubber {
+	return func(i *Interaction) {
+		for _, secret := range secrets {
+			if secret == "" {
+				continue
+			}
+			i.Request.URL = strings.ReplaceAll(i.Request.URL, secret, Redacted)
+			i.Request.Body = Body(strings.ReplaceAll(string(i.Request.Body), secret, Redacted))
+			i.Response.Body = Body(strings.ReplaceAll(string(i.Response.Body), secret, Redacted))
+			for _, h := range []http.Header{i.Request.Header, i.Response.Header} {
+				for key, values := range h {
+					for j, v := range values {
+						h[key][j] = strings.ReplaceAll(v, secret, Redacted)
+					}
+				}
+			}
+		}
+	}
+}
+
+func scrubURL(raw string, params []string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	query := u.Query()
+	changed := false
+	for _, param := range params {
+		if query.Has(param) {
+			query.Set(param, Redacted)
+			changed = true
+		}
+	}
+	if changed {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+func scrubJSON(body Body, fields []string) Body {
+	var v any
+	if len(body) == 0 || json.Unmarshal(body, &v) != nil {
+		return body
+	}
+	v = scrubValue(v, fields)
+	data, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return data
+}
+
+func scrubValue(v any, fields []string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if containsFold(fields, key) {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = scrubValue(value, fields)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = scrubValue(value, fields)
+		}
+	}
+	return v
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
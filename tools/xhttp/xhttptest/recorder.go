@@ -0,0 +1,225 @@
+package xhttptest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// RecordEnv 设置为 1 或 true 时 NewRecorder 默认使用 ModeRecord
+const RecordEnv = "XHTTPTEST_RECORD"
+
+type Mode int
+
+const (
+	// ModeReplay 从 cassette 回放，不发送真实请求
+	ModeReplay Mode = iota
+	// ModeRecord 发送真实请求并在测试结束时写入 cassette
+	ModeRecord
+)
+
+// Matcher 判断请求是否与录制的请求匹配，录制的请求已经过脱敏
+type Matcher func(req *http.Request, body []byte, recorded *Request) bool
+
+// DefaultMatcher 按方法与脱敏后的 URL 匹配
+func DefaultMatcher(scrubQuery []string) Matcher {
+	return func(req *http.Request, body []byte, recorded *Request) bool {
+		return req.Method == recorded.Method && scrubURL(req.URL.String(), scrubQuery) == recorded.URL
+	}
+}
+
+// BodyMatcher 在 DefaultMatcher 的基础上要求请求体一致
+func BodyMatcher(scrubQuery []string) Matcher {
+	match := DefaultMatcher(scrubQuery)
+	return func(req *http.Request, body []byte, recorded *Request) bool {
+		return match(req, body, recorded) && bytes.Equal(body, recorded.Body)
+	}
+}
+
+type recorderOptions struct {
+	mode      Mode
+	transport http.RoundTripper
+	scrubbers []Scrubber
+	matcher   Matcher
+}
+
+type Option func(*recorderOptions)
+
+// WithMode 默认根据 RecordEnv 决定
+func WithMode(mode Mode) Option {
+	return func(o *recorderOptions) {
+		o.mode = mode
+	}
+}
+
+// WithTransport 录制时使用的真实 Transport，默认 http.DefaultTransport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *recorderOptions) {
+		o.transport = transport
+	}
+}
+
+// WithScrubber 追加脱敏规则，DefaultScrubHeaders 与 DefaultScrubQuery 总是生效
+func WithScrubber(scrubbers ...Scrubber) Option {
+	return func(o *recorderOptions) {
+		o.scrubbers = append(o.scrubbers, scrubbers...)
+	}
+}
+
+func WithMatcher(matcher Matcher) Option {
+	return func(o *recorderOptions) {
+		o.matcher = matcher
+	}
+}
+
+// Recorder 录制或回放 HTTP 请求的 Transport
+// 回放时每条录制只使用一次，按录制顺序查找第一条匹配的记录
+//
+//	rec := xhttptest.NewRecorder(t, "testdata/send_email.json")
+//	client := NewClient(WithHTTPClient(rec.Client()))
+//
+// 设置 XHTTPTEST_RECORD=1 运行测试即可重新录制
+type Recorder struct {
+	t        testing.TB
+	path     string
+	opts     recorderOptions
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// NewRecorder 回放模式下 cassette 不存在时测试失败，录制模式在测试结束时保存 cassette
+func NewRecorder(t testing.TB, path string, options ...Option) *Recorder {
+	t.Helper()
+
+	opts := recorderOptions{
+		transport: http.DefaultTransport,
+	}
+	if record, _ := strconv.ParseBool(os.Getenv(RecordEnv)); record {
+		opts.mode = ModeRecord
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	opts.scrubbers = append([]Scrubber{ScrubHeaders(DefaultScrubHeaders...), ScrubQuery(DefaultScrubQuery...)}, opts.scrubbers...)
+	if opts.matcher == nil {
+		opts.matcher = DefaultMatcher(DefaultScrubQuery)
+	}
+
+	r := &Recorder{t: t, path: path, opts: opts, cassette: &Cassette{}}
+	switch opts.mode {
+	case ModeRecord:
+		t.Cleanup(func() {
+			if err := r.cassette.Save(path); err != nil {
+				t.Errorf("xhttptest: save cassette %s: %v", path, err)
+			}
+		})
+	default:
+		cassette, err := LoadCassette(path)
+		if err != nil {
+			t.Fatalf("xhttptest: load cassette %s: %v, run with %s=1 to record", path, err, RecordEnv)
+		}
+		r.cassette = cassette
+		r.used = make([]bool, len(cassette.Interactions))
+	}
+	return r
+}
+
+// Client 返回使用 Recorder 作为 Transport 的 http.Client
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.opts.mode == ModeRecord {
+		return r.record(req, body)
+	}
+	return r.replay(req, body)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	resp, err := r.opts.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := &Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: req.Header.Clone(),
+			Body:   body,
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       respBody,
+		},
+	}
+	for _, scrub := range r.opts.scrubbers {
+		scrub(interaction)
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || !r.opts.matcher(req, body, &interaction.Request) {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	err := fmt.Errorf("xhttptest: no recorded interaction for %s %s in %s", req.Method, req.URL, r.path)
+	r.t.Error(err)
+	return nil, err
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, errors.Join(errors.New("xhttptest: read request body"), err)
+	}
+	return body, nil
+}
@@ -0,0 +1,115 @@
+package xhttptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// Server 可编程的假服务，按方法与路径注册 handler，测试结束时自动关闭
+// 未注册的请求返回 404 并使测试失败
+//
+//	srv := xhttptest.NewServer(t)
+//	srv.JSON(http.MethodPost, "/emails", http.StatusOK, map[string]string{"id": "email-1"})
+//	client := resend.NewResendClient(resend.WithBaseURL(srv.URL))
+type Server struct {
+	*httptest.Server
+
+	t        testing.TB
+	mu       sync.Mutex
+	routes   map[string][]http.HandlerFunc
+	requests []*Request
+}
+
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		t:      t,
+		routes: make(map[string][]http.HandlerFunc),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Handle 注册 handler，同一路由注册多个 handler 时按顺序各处理一次请求，最后一个处理之后的所有请求
+func (s *Server) Handle(method, path string, handlers ...http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := method + " " + path
+	s.routes[key] = append(s.routes[key], handlers...)
+}
+
+// JSON 注册返回 JSON 的 handler
+func (s *Server) JSON(method, path string, status int, body any) {
+	s.Handle(method, path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	})
+}
+
+// SSE 注册返回 text/event-stream 的 handler，每个 data 作为一个事件发送
+func (s *Server) SSE(method, path string, data ...string) {
+	s.Handle(method, path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for _, d := range data {
+			fmt.Fprintf(w, "data: %s\n\n", d)
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+	})
+}
+
+// Requests 返回收到的全部请求
+func (s *Server) Requests() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Request(nil), s.requests...)
+}
+
+// LastRequest 返回最后一个请求，没有请求时返回 nil
+func (s *Server) LastRequest() *Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) == 0 {
+		return nil
+	}
+	return s.requests[len(s.requests)-1]
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	s.mu.Lock()
+	s.requests = append(s.requests, &Request{
+		Method: r.Method,
+		URL:    r.URL.String(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	key := r.Method + " " + r.URL.Path
+	handlers := s.routes[key]
+	var handler http.HandlerFunc
+	if len(handlers) > 0 {
+		handler = handlers[0]
+		if len(handlers) > 1 {
+			s.routes[key] = handlers[1:]
+		}
+	}
+	s.mu.Unlock()
+
+	if handler == nil {
+		s.t.Errorf("xhttptest: unexpected request %s %s", r.Method, r.URL)
+		http.NotFound(w, r)
+		return
+	}
+	handler(w, r)
+}
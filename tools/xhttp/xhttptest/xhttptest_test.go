@@ -0,0 +1,78 @@
+package xhttptest
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	srv := NewServer(t)
+	srv.JSON(http.MethodPost, "/v1/send", http.StatusOK, map[string]string{"id": "msg-1", "token": "server-secret"})
+
+	path := filepath.Join(t.TempDir(), "send.json")
+	send := func(t *testing.T, client *http.Client) string {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/v1/send?access_token=abc123", strings.NewReader(`{"to":"a@b.c","password":"p@ss"}`))
+		req.Header.Set("Authorization", "Bearer sk-live")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	var recorded string
+	t.Run("record", func(t *testing.T) {
+		rec := NewRecorder(t, path, WithMode(ModeRecord), WithScrubber(ScrubJSONFields("password", "token")))
+		recorded = send(t, rec.Client())
+	})
+	if !strings.Contains(recorded, "server-secret") {
+		t.Fatalf("recording should return the real response, got %s", recorded)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"abc123", "sk-live", "p@ss", "server-secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("cassette contains secret %q:\n%s", secret, data)
+		}
+	}
+
+	srv.Close()
+	rec := NewRecorder(t, path, WithMode(ModeReplay))
+	replayed := send(t, rec.Client())
+	if !strings.Contains(replayed, `"id":"msg-1"`) {
+		t.Fatalf("replayed = %s", replayed)
+	}
+}
+
+func TestServerSequence(t *testing.T) {
+	srv := NewServer(t)
+	srv.Handle(http.MethodGet, "/status",
+		func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) },
+		func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) },
+	)
+
+	var got []int
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(srv.URL + "/status")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		got = append(got, resp.StatusCode)
+	}
+	if got[0] != 503 || got[1] != 200 || got[2] != 200 {
+		t.Fatalf("got = %v", got)
+	}
+	if n := len(srv.Requests()); n != 3 {
+		t.Fatalf("requests = %d", n)
+	}
+}
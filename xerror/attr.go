@@ -0,0 +1,151 @@
+package xerror
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind 错误类别，用于在不依赖具体哨兵错误的情况下判断错误语义
+type Kind string
+
+const (
+	KindUnknown         Kind = ""
+	KindInvalidArgument Kind = "invalid_argument"
+	KindNotFound        Kind = "not_found"
+	KindAlreadyExists   Kind = "already_exists"
+	KindUnauthenticated Kind = "unauthenticated"
+	KindPermission      Kind = "permission_denied"
+	KindRateLimited     Kind = "rate_limited"
+	KindTimeout         Kind = "timeout"
+	KindUnavailable     Kind = "unavailable"
+	KindInternal        Kind = "internal"
+)
+
+// Field 附加在错误上的键值对
+type Field struct {
+	Key   string
+	Value any
+}
+
+// StackTracer 可以提供调用栈的错误
+type StackTracer interface {
+	StackTrace() []string
+}
+
+// WithKind 为错误设置类别，err 不是 xerror 时会记录调用位置
+func WithKind(err error, kind Kind) error {
+	if err == nil {
+		return nil
+	}
+	xe := asXerror(err, 2)
+	xe.kind = kind
+	return xe
+}
+
+// WithCode 为错误设置业务错误码
+func WithCode(err error, code int) error {
+	if err == nil {
+		return nil
+	}
+	xe := asXerror(err, 2)
+	xe.code = code
+	return xe
+}
+
+// WithFields 为错误附加键值对，kv 依次为 key, value，key 必须为 string
+func WithFields(err error, kv ...any) error {
+	if err == nil {
+		return nil
+	}
+	xe := asXerror(err, 2)
+	fields := make([]Field, 0, len(xe.fields)+len(kv)/2)
+	fields = append(fields, xe.fields...)
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		fields = append(fields, Field{Key: key, Value: kv[i+1]})
+	}
+	xe.fields = fields
+	return xe
+}
+
+// KindOf 返回错误链上第一个设置过的类别
+func KindOf(err error) Kind {
+	kind := KindUnknown
+	walk(err, func(xe xerror) bool {
+		if xe.kind != KindUnknown {
+			kind = xe.kind
+			return false
+		}
+		return true
+	})
+	return kind
+}
+
+// CodeOf 返回错误链上第一个设置过的错误码，未设置返回 0
+func CodeOf(err error) int {
+	code := 0
+	walk(err, func(xe xerror) bool {
+		if xe.code != 0 {
+			code = xe.code
+			return false
+		}
+		return true
+	})
+	return code
+}
+
+// FieldsOf 返回错误链上所有的键值对，外层在前
+func FieldsOf(err error) []Field {
+	var fields []Field
+	walk(err, func(xe xerror) bool {
+		fields = append(fields, xe.fields...)
+		return true
+	})
+	return fields
+}
+
+// StackTrace 返回错误链上第一个 StackTracer 的调用栈
+func StackTrace(err error) []string {
+	var st StackTracer
+	if errors.As(err, &st) {
+		return st.StackTrace()
+	}
+	return nil
+}
+
+// asXerror 将 err 转为 xerror，非 xerror 时在 skip 层调用位置包装
+func asXerror(err error, skip int) xerror {
+	if xe, ok := err.(xerror); ok {
+		return xe
+	}
+	return newWithCaller(err, skip+1)
+}
+
+// walk 深度优先遍历错误链上的 xerror，fn 返回 false 时停止
+func walk(err error, fn func(xerror) bool) bool {
+	for err != nil {
+		if xe, ok := err.(xerror); ok {
+			if !fn(xe) {
+				return false
+			}
+		}
+
+		switch u := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, child := range u.Unwrap() {
+				if !walk(child, fn) {
+					return false
+				}
+			}
+			return true
+		case interface{ Unwrap() error }:
+			err = u.Unwrap()
+		default:
+			return true
+		}
+	}
+	return true
+}
@@ -3,25 +3,79 @@ package xerror
 import (
 	"errors"
 	"fmt"
-	"runtime"
-	"strings"
+	"io"
 )
 
 type xerror struct {
-	err        error
-	stacktrace []string
+	err    error
+	kind   Kind
+	code   int
+	fields []Field
+	// callers 每次 Wrap 的调用位置，外层在前
+	callers []uintptr
+	// stack 创建时的完整调用栈，仅在 SetFullStack(true) 时记录
+	stack []uintptr
 }
 
+// Error 只返回错误信息，调用栈通过 StackTrace 或 %+v 获取
 func (e xerror) Error() string {
-	return fmt.Sprintf("%s\n%s", e.err, strings.Join(e.stacktrace, "\n"))
+	return e.err.Error()
 }
 
-func New(message string) error {
-	_, file, line, _ := runtime.Caller(1)
-	return xerror{
-		err:        errors.New(message),
-		stacktrace: []string{fmt.Sprintf("%s %d", file, line)},
+func (e xerror) Unwrap() error {
+	return e.err
+}
+
+// Is 使 errors.Is 可以直接比较 xerror 类型的哨兵错误
+func (e xerror) Is(target error) bool {
+	if t, ok := target.(xerror); ok {
+		return errors.Is(e.err, t.err)
 	}
+	return false
+}
+
+// StackTrace 返回调用位置，最外层的调用在前，符号化在调用时才进行
+func (e xerror) StackTrace() []string {
+	return symbolize(e.callers, e.stack)
+}
+
+// Format 实现 fmt.Formatter，%v/%s 只输出错误信息，%+v 额外输出调用栈，其他动词按 Error() 字符串格式化
+func (e xerror) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			io.WriteString(s, e.Error())
+			for _, frame := range e.StackTrace() {
+				io.WriteString(s, "\n")
+				io.WriteString(s, frame)
+			}
+			if je, ok := e.err.(joinError); ok {
+				for i, child := range je.errs {
+					fmt.Fprintf(s, "\n[%d] %+v", i, child)
+				}
+			}
+			return
+		}
+		io.WriteString(s, e.Error())
+	case 's':
+		io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	default:
+		// 其他动词按 Error() 字符串处理，与未实现 Formatter 的 error 一致，如 %x 输出十六进制，%d 输出 %!d(string=...)
+		fmt.Fprintf(s, fmt.FormatString(s, verb), e.Error())
+	}
+}
+
+func New(message string) error {
+	return newWithCaller(errors.New(message), 2)
+}
+
+// NewWithKind 创建指定类别的错误
+func NewWithKind(kind Kind, message string) error {
+	xe := newWithCaller(errors.New(message), 2)
+	xe.kind = kind
+	return xe
 }
 
 func Wrap(err error) error {
@@ -32,35 +86,29 @@ func WrapWithMessage(err error, message string) error {
 	return wrapWithCallerAndMessage(err, 2, message)
 }
 
-func wrapWithCaller(err error, skip int) error {
-	_, file, line, _ := runtime.Caller(skip)
+// newWithCaller 创建 xerror，开启完整调用栈时记录 skip 层起的调用栈，否则只记录调用位置
+func newWithCaller(err error, skip int) xerror {
+	if stack := captureStack(skip); stack != nil {
+		return xerror{err: err, stack: stack}
+	}
+	return xerror{err: err, callers: []uintptr{caller(skip)}}
+}
 
+func wrapWithCaller(err error, skip int) error {
 	if xe, ok := err.(xerror); ok {
-		xe.stacktrace = append([]string{fmt.Sprintf("%s %d", file, line)}, xe.stacktrace...)
+		xe.callers = append([]uintptr{caller(skip)}, xe.callers...)
 		return xe
 	}
 
-	xe := xerror{
-		err:        err,
-		stacktrace: []string{fmt.Sprintf("%s %d", file, line)},
-	}
-
-	return xe
+	return newWithCaller(err, skip+1)
 }
 
 func wrapWithCallerAndMessage(err error, skip int, message string) error {
-	_, file, line, _ := runtime.Caller(skip)
-
 	if xe, ok := err.(xerror); ok {
-		xe.stacktrace = append([]string{fmt.Sprintf("%s %d", file, line)}, xe.stacktrace...)
+		xe.callers = append([]uintptr{caller(skip)}, xe.callers...)
 		xe.err = fmt.Errorf("%s: %w", message, xe.err)
 		return xe
 	}
 
-	xe := xerror{
-		err:        fmt.Errorf("%s: %w", message, err),
-		stacktrace: []string{fmt.Sprintf("%s %d", file, line)},
-	}
-
-	return xe
+	return newWithCaller(fmt.Errorf("%s: %w", message, err), skip+1)
 }
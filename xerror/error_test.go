@@ -0,0 +1,125 @@
+package xerror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+type codedError struct {
+	code int
+}
+
+func (e *codedError) Error() string {
+	return fmt.Sprintf("coded %d", e.code)
+}
+
+func TestUnwrapAndAs(t *testing.T) {
+	err := WrapWithMessage(Wrap(&codedError{code: 7}), "load user")
+
+	var target *codedError
+	if !errors.As(err, &target) || target.code != 7 {
+		t.Fatalf("errors.As failed: %v", err)
+	}
+	if !errors.Is(WrapWithMessage(io.EOF, "read"), io.EOF) {
+		t.Fatal("errors.Is should see through xerror")
+	}
+
+	sentinel := New("sentinel")
+	if !errors.Is(WrapWithMessage(Wrap(sentinel), "outer"), sentinel) {
+		t.Fatal("errors.Is should match xerror sentinel")
+	}
+	if !Is(Wrap(sentinel), sentinel) {
+		t.Fatal("xerror.Is should stay compatible")
+	}
+}
+
+func TestFormatAndStackTrace(t *testing.T) {
+	err := WrapWithMessage(New("boom"), "handler")
+
+	if err.Error() != "handler: boom" {
+		t.Fatalf("unexpected message: %q", err.Error())
+	}
+	if got := fmt.Sprintf("%v", err); got != "handler: boom" {
+		t.Fatalf("%%v should not contain stack: %q", got)
+	}
+	if got := fmt.Sprintf("%+v", err); !strings.Contains(got, "error_test.go") {
+		t.Fatalf("%%+v should contain stack: %q", got)
+	}
+	if got := fmt.Sprintf("%x", err); got != fmt.Sprintf("%x", "handler: boom") {
+		t.Fatalf("%%x should format the message as hex: %q", got)
+	}
+	if got := fmt.Sprintf("%d", err); got != "%!d(string=handler: boom)" {
+		t.Fatalf("unsupported verbs should fall back to the message: %q", got)
+	}
+	if len(StackTrace(fmt.Errorf("wrapped: %w", err))) != 2 {
+		t.Fatalf("unexpected stack: %v", StackTrace(err))
+	}
+}
+
+func TestKindCodeFields(t *testing.T) {
+	err := WithFields(WithCode(NewWithKind(KindNotFound, "user not found"), 40401), "user_id", 42)
+	err = WithFields(fmt.Errorf("service: %w", err), "op", "get_user")
+
+	if KindOf(err) != KindNotFound {
+		t.Fatalf("unexpected kind: %q", KindOf(err))
+	}
+	if CodeOf(err) != 40401 {
+		t.Fatalf("unexpected code: %d", CodeOf(err))
+	}
+
+	fields := FieldsOf(err)
+	if len(fields) != 2 || fields[0].Key != "op" || fields[1].Key != "user_id" {
+		t.Fatalf("unexpected fields: %v", fields)
+	}
+}
+
+func TestFullStackAndJoin(t *testing.T) {
+	SetFullStack(true)
+	defer SetFullStack(false)
+
+	first := New("first")
+	if len(StackTrace(first)) < 2 {
+		t.Fatalf("expected full stack, got %v", StackTrace(first))
+	}
+
+	second := WithKind(io.ErrUnexpectedEOF, KindInvalidArgument)
+	joined := Join(first, nil, second)
+
+	if !errors.Is(joined, io.ErrUnexpectedEOF) || !errors.Is(joined, first) {
+		t.Fatal("joined error should match children")
+	}
+	if len(Errors(joined)) != 2 {
+		t.Fatalf("unexpected children: %v", Errors(joined))
+	}
+	if Join(nil, nil) != nil {
+		t.Fatal("join of nil errors should be nil")
+	}
+
+	data, err := json.Marshal(WithFields(joined, "batch", 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		Message string         `json:"message"`
+		Fields  map[string]any `json:"fields"`
+		Errors  []struct {
+			Message string   `json:"message"`
+			Kind    string   `json:"kind"`
+			Stack   []string `json:"stack"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Message != "first; unexpected EOF" || decoded.Fields["batch"] != float64(3) {
+		t.Fatalf("unexpected json: %s", data)
+	}
+	if len(decoded.Errors) != 2 || len(decoded.Errors[0].Stack) == 0 || decoded.Errors[1].Kind != string(KindInvalidArgument) {
+		t.Fatalf("unexpected children json: %s", data)
+	}
+}
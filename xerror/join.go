@@ -0,0 +1,51 @@
+package xerror
+
+import (
+	"errors"
+	"strings"
+)
+
+// joinError 多个错误的聚合，每个子错误保留各自的调用栈
+type joinError struct {
+	errs []error
+}
+
+func (e joinError) Error() string {
+	msgs := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e joinError) Unwrap() []error {
+	return e.errs
+}
+
+// Join 聚合多个错误，忽略 nil，全部为 nil 时返回 nil
+// 返回的错误支持 errors.Is / errors.As 匹配任一子错误，%+v 与 JSON 输出包含每个子错误的调用栈
+func Join(errs ...error) error {
+	nonNil := make([]error, 0, len(errs))
+	for _, err := range errs {
+		if err != nil {
+			nonNil = append(nonNil, err)
+		}
+	}
+	if len(nonNil) == 0 {
+		return nil
+	}
+
+	return newWithCaller(joinError{errs: nonNil}, 2)
+}
+
+// Errors 返回 Join 聚合的子错误，err 不是聚合错误时返回 []error{err}
+func Errors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	var je joinError
+	if errors.As(err, &je) {
+		return append([]error(nil), je.errs...)
+	}
+	return []error{err}
+}
@@ -0,0 +1,70 @@
+package xerror
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// jsonError xerror 的 JSON 结构
+type jsonError struct {
+	Message string         `json:"message"`
+	Kind    Kind           `json:"kind,omitempty"`
+	Code    int            `json:"code,omitempty"`
+	Fields  map[string]any `json:"fields,omitempty"`
+	Stack   []string       `json:"stack,omitempty"`
+	Cause   any            `json:"cause,omitempty"`
+	Errors  []any          `json:"errors,omitempty"`
+}
+
+// MarshalJSON 将错误序列化为结构化对象，便于日志系统检索
+// 内层的 xerror 放在 cause 中，Join 的子错误放在 errors 中
+func (e xerror) MarshalJSON() ([]byte, error) {
+	je := jsonError{
+		Message: e.Error(),
+		Kind:    e.kind,
+		Code:    e.code,
+		Stack:   e.StackTrace(),
+	}
+
+	if len(e.fields) > 0 {
+		je.Fields = make(map[string]any, len(e.fields))
+		for _, f := range e.fields {
+			je.Fields[f.Key] = f.Value
+		}
+	}
+
+	// 沿 Unwrap 链找到最近的聚合错误或内层 xerror
+	for err := e.err; err != nil; err = errors.Unwrap(err) {
+		if joined, ok := err.(joinError); ok {
+			for _, child := range joined.errs {
+				je.Errors = append(je.Errors, marshalable(child))
+			}
+			break
+		}
+		if inner, ok := err.(xerror); ok {
+			je.Cause = inner
+			break
+		}
+	}
+
+	return json.Marshal(je)
+}
+
+// Marshal 将任意错误转为可 JSON 序列化的值，非 xerror 只保留错误信息
+func Marshal(err error) any {
+	if err == nil {
+		return nil
+	}
+	return marshalable(err)
+}
+
+func marshalable(err error) any {
+	if m, ok := err.(json.Marshaler); ok {
+		return m
+	}
+	var xe xerror
+	if errors.As(err, &xe) {
+		return jsonError{Message: err.Error(), Cause: xe}
+	}
+	return jsonError{Message: err.Error()}
+}
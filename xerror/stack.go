@@ -0,0 +1,62 @@
+package xerror
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+)
+
+// maxStackDepth 完整调用栈的最大深度
+const maxStackDepth = 32
+
+var fullStack atomic.Bool
+
+// SetFullStack 开启后 New 以及首次 Wrap 非 xerror 错误时记录完整调用栈，而不只是调用位置
+// 只记录程序计数器，符号化推迟到 StackTrace / %+v / JSON 输出时进行
+func SetFullStack(enabled bool) {
+	fullStack.Store(enabled)
+}
+
+// caller 返回调用方向上第 skip 层的程序计数器，skip 含义与 runtime.Caller 相同
+func caller(skip int) uintptr {
+	var pcs [1]uintptr
+	runtime.Callers(skip+2, pcs[:])
+	return pcs[0]
+}
+
+// captureStack 未开启完整调用栈时返回 nil
+func captureStack(skip int) []uintptr {
+	if !fullStack.Load() {
+		return nil
+	}
+
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+2, pcs)
+	return pcs[:n]
+}
+
+// symbolize 将 Wrap 调用位置与完整调用栈转换为 "file line" 格式
+func symbolize(callers []uintptr, stack []uintptr) []string {
+	if len(callers) == 0 && len(stack) == 0 {
+		return nil
+	}
+
+	res := make([]string, 0, len(callers)+len(stack))
+	for _, pc := range callers {
+		// 每个 Wrap 位置只取最内层的逻辑帧，与 runtime.Caller 一致
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		res = append(res, fmt.Sprintf("%s %d", frame.File, frame.Line))
+	}
+
+	frames := runtime.CallersFrames(stack)
+	for {
+		frame, more := frames.Next()
+		if frame.PC != 0 {
+			res = append(res, fmt.Sprintf("%s %d", frame.File, frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return res
+}
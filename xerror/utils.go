@@ -2,16 +2,7 @@ package xerror
 
 import "errors"
 
+// Is 等价于 errors.Is，xerror 实现了 Unwrap 与 Is，保留此函数以兼容旧代码
 func Is(err error, target error) bool {
-
-	if xerr, ok := err.(xerror); ok {
-
-		if targerErr, ok := target.(xerror); ok {
-			return errors.Is(xerr.err, targerErr.err)
-		}
-
-		return errors.Is(xerr.err, target)
-	}
-
 	return errors.Is(err, target)
 }